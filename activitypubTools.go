@@ -0,0 +1,539 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/activitypub"
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/httpsig"
+	"github.com/YaroslavalsoraY/Chirpy/internal/timestamps"
+	"github.com/google/uuid"
+)
+
+func writeActivityJson(w http.ResponseWriter, v any) {
+	respData, err := json.Marshal(v)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", activitypub.ContentType)
+	w.Write(respData)
+}
+
+func (cfg *apiConfig) HandlerWebFinger(w http.ResponseWriter, r *http.Request) {
+	base := cfg.absoluteURL(r, "")
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	resource := r.URL.Query().Get("resource")
+
+	var user database.User
+	if acct, ok := strings.CutPrefix(resource, "acct:"); ok {
+		handle, host, found := strings.Cut(strings.TrimPrefix(acct, "@"), "@")
+		if !found || handle == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !strings.EqualFold(host, baseURL.Host) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		user, err = cfg.queries.GetUserByHandle(r.Context(), handle)
+	} else if userID, ok := localID(base, "/users/", resource); ok {
+		user, err = cfg.queries.GetUserByID(r.Context(), userID)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	// only users with a handle have an acct: address
+	if !user.Handle.Valid {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	actor := actorURL(base, user.ID)
+	respData, err := json.Marshal(activitypub.WebFinger{
+		Subject: "acct:" + user.Handle.String + "@" + baseURL.Host,
+		Aliases: []string{actor},
+		Links: []activitypub.Link{
+			{Rel: "self", Type: activitypub.ContentType, Href: actor},
+			{Rel: "http://webfinger.net/rel/profile-page", Type: "text/html", Href: base + "/api/users/" + user.Handle.String},
+		},
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/jrd+json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(respData)
+}
+
+func (cfg *apiConfig) HandlerActor(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	user, err := cfg.queries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	key, err := cfg.actorKey(r.Context(), user.ID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	base := cfg.absoluteURL(r, "")
+	id := actorURL(base, user.ID)
+	actor := activitypub.Actor{
+		Context:           []string{activitypub.Context, activitypub.SecurityContext},
+		ID:                id,
+		Type:              "Person",
+		PreferredUsername: user.ID.String(),
+		Name:              user.DisplayName,
+		Summary:           html.EscapeString(user.Bio),
+		Inbox:             id + "/inbox",
+		Outbox:            id + "/outbox",
+		Followers:         id + "/followers",
+		Endpoints:         &activitypub.Endpoints{SharedInbox: base + "/inbox"},
+		PublicKey: activitypub.PublicKey{
+			ID:           id + "#main-key",
+			Owner:        id,
+			PublicKeyPem: key.PublicKeyPem,
+		},
+	}
+	if user.Handle.Valid {
+		actor.PreferredUsername = user.Handle.String
+		actor.URL = base + "/api/users/" + user.Handle.String
+	}
+	if user.AvatarUrl != "" {
+		actor.Icon = &activitypub.Image{Type: "Image", URL: user.AvatarUrl}
+	}
+	if user.CreatedAt.Valid {
+		actor.Published = &user.CreatedAt.Time
+	}
+
+	writeActivityJson(w, actor)
+}
+
+// HandlerOutbox serves the user's public chirps as Create activities. The bare collection only
+// has the count, ?page=true walks the chirps newest first with the usual cursor.
+func (cfg *apiConfig) HandlerOutbox(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	user, err := cfg.queries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	base := cfg.absoluteURL(r, "")
+	outbox := actorURL(base, user.ID) + "/outbox"
+
+	if r.URL.Query().Get("page") != "true" {
+		total, err := cfg.queries.CountOutboxChirps(r.Context(), user.ID)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		writeActivityJson(w, activitypub.OrderedCollection{
+			Context:    activitypub.Context,
+			ID:         outbox,
+			Type:       "OrderedCollection",
+			TotalItems: total,
+			First:      outbox + "?page=true",
+		})
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// a NULL viewer only sees public chirps
+	chirps, err := cfg.queries.ListChirpsDesc(r.Context(), database.ListChirpsDescParams{
+		AuthorID:        uuid.NullUUID{UUID: user.ID, Valid: true},
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		MaxRows:         page.limit + 1,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	chirps, nextCursor := trimChirpsPage(chirps, page.limit)
+
+	items := []any{}
+	for _, el := range chirps {
+		activity, err := chirpActivity(base, "Create", el)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		items = append(items, activity)
+	}
+
+	collectionPage := activitypub.OrderedCollectionPage{
+		Context:      activitypub.Context,
+		ID:           outbox + "?" + r.URL.RawQuery,
+		Type:         "OrderedCollectionPage",
+		PartOf:       outbox,
+		OrderedItems: items,
+	}
+	if nextCursor != "" {
+		collectionPage.Next = outbox + "?page=true&cursor=" + url.QueryEscape(nextCursor)
+	}
+
+	writeActivityJson(w, collectionPage)
+}
+
+// HandlerActorFollowers only tells the count, local and remote followers together.
+func (cfg *apiConfig) HandlerActorFollowers(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	user, err := cfg.queries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	total, err := cfg.queries.CountFollowers(r.Context(), user.ID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeActivityJson(w, activitypub.OrderedCollection{
+		Context:    activitypub.Context,
+		ID:         actorURL(cfg.absoluteURL(r, ""), user.ID) + "/followers",
+		Type:       "OrderedCollection",
+		TotalItems: total,
+	})
+}
+
+func (cfg *apiConfig) HandlerNote(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	chirp, err := cfg.queries.GetOneChirp(r.Context(), chirpID)
+	if err != nil || chirp.ScheduledAt.Valid || chirp.Visibility != visibilityPublic {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if chirp.DeletedAt.Valid {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+
+	note := chirpToNote(cfg.absoluteURL(r, ""), chirp)
+	note.Context = activitypub.Context
+	writeActivityJson(w, note)
+}
+
+// remoteActor finds the actor owning keyID, fetching it the first time the key signs something.
+func (cfg *apiConfig) remoteActor(ctx context.Context, keyID string) (database.RemoteActor, error) {
+	actor, err := cfg.queries.GetRemoteActorByKeyID(ctx, keyID)
+	if err == nil {
+		return actor, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.RemoteActor{}, err
+	}
+
+	// FetchActor makes sure the actor, its key and keyID all share one origin
+	fetched, err := cfg.apClient.FetchActor(ctx, keyID)
+	if err != nil {
+		return database.RemoteActor{}, err
+	}
+	if fetched.PublicKey.ID != keyID || fetched.PublicKey.Owner != fetched.ID {
+		return database.RemoteActor{}, fmt.Errorf("Key %s does not belong to %s", keyID, fetched.ID)
+	}
+
+	// the upsert leaves a known actor alone when the new key is from another origin, no row comes back
+	actor, err = cfg.queries.UpsertRemoteActor(ctx, database.UpsertRemoteActorParams{
+		Uri:          fetched.ID,
+		Username:     fetched.PreferredUsername,
+		Inbox:        fetched.Inbox,
+		SharedInbox:  fetched.SharedInbox(),
+		KeyID:        fetched.PublicKey.ID,
+		PublicKeyPem: fetched.PublicKey.PublicKeyPem,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.RemoteActor{}, fmt.Errorf("Key %s may not replace the key of %s", keyID, fetched.ID)
+	}
+	return actor, err
+}
+
+// HandlerInbox takes activities from other servers, both on the shared inbox and on a user's
+// own. Every activity has to be signed by the key of the actor it claims to come from.
+func (cfg *apiConfig) HandlerInbox(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, activitypub.MaxBodySize+1))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if len(body) > activitypub.MaxBodySize {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	sig, err := httpsig.ParseSignature(r)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	actor, err := cfg.remoteActor(r.Context(), sig.KeyID)
+	if errors.Is(err, activitypub.ErrRateLimited) {
+		fmt.Println(err)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	publicKey, err := httpsig.ParsePublicKey(actor.PublicKeyPem)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	err = httpsig.Verify(r, body, sig, publicKey)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	activity := activitypub.Activity{}
+	err = json.Unmarshal(body, &activity)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if activity.Actor != actor.Uri {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	base := cfg.absoluteURL(r, "")
+
+	switch activity.Type {
+	case "Follow":
+		err = cfg.handleRemoteFollow(r.Context(), base, actor, activity, body)
+	case "Undo":
+		err = cfg.handleRemoteUndo(r.Context(), base, actor, activity)
+	case "Like":
+		err = cfg.handleRemoteLike(r.Context(), base, actor, activity)
+	case "Create":
+		err = cfg.handleRemoteCreate(r.Context(), base, actor, activity)
+	}
+	if errors.Is(err, errNotLocal) {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, errBadActivity) {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// activities we have no use for are accepted and dropped
+	w.WriteHeader(http.StatusAccepted)
+}
+
+var (
+	errNotLocal    = errors.New("Object not found on this server")
+	errBadActivity = errors.New("Malformed activity")
+)
+
+// handleRemoteFollow records the follow and queues an Accept, which embeds the Follow as sent.
+func (cfg *apiConfig) handleRemoteFollow(ctx context.Context, base string, actor database.RemoteActor, activity activitypub.Activity, body []byte) error {
+	userID, ok := localID(base, "/users/", activity.ObjectID())
+	if !ok {
+		return errNotLocal
+	}
+
+	user, err := cfg.queries.GetUserByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errNotLocal
+	}
+	if err != nil {
+		return err
+	}
+
+	err = cfg.queries.InsertRemoteFollow(ctx, database.InsertRemoteFollowParams{
+		UserID:     user.ID,
+		ActorID:    actor.ID,
+		ActivityID: activity.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	local := actorURL(base, user.ID)
+	return cfg.enqueueActivity(ctx, user.ID, []string{actor.Inbox}, activitypub.Activity{
+		Context: activitypub.Context,
+		ID:      local + "#accepts/" + uuid.New().String(),
+		Type:    "Accept",
+		Actor:   local,
+		Object:  body,
+		To:      []string{actor.Uri},
+	})
+}
+
+// handleRemoteUndo takes back a Follow or Like. Servers refer to the undone activity by its id,
+// the embedded object lets us also match on what it targeted.
+func (cfg *apiConfig) handleRemoteUndo(ctx context.Context, base string, actor database.RemoteActor, activity activitypub.Activity) error {
+	switch activity.ObjectType() {
+	case "Follow":
+		userID, ok := localID(base, "/users/", activity.InnerObjectID())
+		return cfg.queries.DeleteRemoteFollow(ctx, database.DeleteRemoteFollowParams{
+			ActorID:    actor.ID,
+			ActivityID: activity.ObjectID(),
+			UserID:     uuid.NullUUID{UUID: userID, Valid: ok},
+		})
+	case "Like":
+		chirpID, ok := localID(base, "/chirps/", activity.InnerObjectID())
+		return cfg.queries.DeleteRemoteLike(ctx, database.DeleteRemoteLikeParams{
+			ActorID:    actor.ID,
+			ActivityID: activity.ObjectID(),
+			ChirpID:    uuid.NullUUID{UUID: chirpID, Valid: ok},
+		})
+	}
+
+	return nil
+}
+
+func (cfg *apiConfig) handleRemoteLike(ctx context.Context, base string, actor database.RemoteActor, activity activitypub.Activity) error {
+	chirpID, ok := localID(base, "/chirps/", activity.ObjectID())
+	if !ok {
+		return errNotLocal
+	}
+
+	chirp, err := cfg.queries.GetOneChirp(ctx, chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errNotLocal
+	}
+	if err != nil {
+		return err
+	}
+	if chirp.DeletedAt.Valid || chirp.ScheduledAt.Valid || chirp.Visibility != visibilityPublic {
+		return errNotLocal
+	}
+
+	return cfg.queries.InsertRemoteLike(ctx, database.InsertRemoteLikeParams{
+		ChirpID:    chirp.ID,
+		ActorID:    actor.ID,
+		ActivityID: activity.ID,
+	})
+}
+
+// handleRemoteCreate keeps incoming notes, linked to the local chirp they reply to if any.
+func (cfg *apiConfig) handleRemoteCreate(ctx context.Context, base string, actor database.RemoteActor, activity activitypub.Activity) error {
+	if activity.ObjectType() != "Note" {
+		return nil
+	}
+
+	note := activitypub.Note{}
+	err := json.Unmarshal(activity.Object, &note)
+	if err != nil || note.ID == "" {
+		return errBadActivity
+	}
+	// the signature only vouches for the actor, not for whoever the note names as author
+	if note.AttributedTo != actor.Uri || !activitypub.SameOrigin(note.ID, actor.Uri) {
+		return errBadActivity
+	}
+	if note.Published.IsZero() {
+		note.Published = time.Now()
+	}
+
+	inReplyTo := uuid.NullUUID{}
+	if chirpID, ok := localID(base, "/chirps/", note.InReplyTo); ok {
+		parent, err := cfg.queries.GetOneChirp(ctx, chirpID)
+		if err == nil && !parent.DeletedAt.Valid && parent.Visibility == visibilityPublic {
+			inReplyTo = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		}
+	}
+
+	return cfg.queries.InsertRemoteNote(ctx, database.InsertRemoteNoteParams{
+		Uri:         note.ID,
+		ActorID:     actor.ID,
+		InReplyTo:   inReplyTo,
+		Content:     note.Content,
+		PublishedAt: timestamps.Column(note.Published),
+	})
+}
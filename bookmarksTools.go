@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/auth"
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+type bookmark struct {
+	CollectionID uuid.NullUUID `json:"collection_id"`
+}
+
+// HandlerBookmarkChirp bookmarks a chirp, or moves an existing bookmark to another collection.
+// The body is optional; without a collection_id the bookmark isn't in any collection.
+func (cfg *apiConfig) HandlerBookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	newBookmark := bookmark{}
+	err = decoder.Decode(&newBookmark)
+	if err != nil && !errors.Is(err, io.EOF) {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	chirp, err := cfg.queries.GetOneChirp(r.Context(), chirpID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if chirp.ScheduledAt.Valid {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	visible, err := cfg.canSeeChirp(r.Context(), userID, chirp)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if !visible {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if chirp.DeletedAt.Valid {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+
+	if newBookmark.CollectionID.Valid {
+		collection, err := cfg.queries.GetCollection(r.Context(), newBookmark.CollectionID.UUID)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		// someone else's collection answers like a missing one so its existence doesn't leak
+		if collection.UserID != userID {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+	}
+
+	err = cfg.queries.BookmarkChirp(r.Context(), database.BookmarkChirpParams{
+		UserID:       userID,
+		ChirpID:      chirpID,
+		CollectionID: newBookmark.CollectionID,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) HandlerUnbookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = cfg.queries.UnbookmarkChirp(r.Context(), database.UnbookmarkChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) HandlerGetBookmarks(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	collectionID := uuid.NullUUID{}
+	collectionIDstring := r.URL.Query().Get("collection_id")
+	if collectionIDstring != "" {
+		parsedID, err := uuid.Parse(collectionIDstring)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		collection, err := cfg.queries.GetCollection(r.Context(), parsedID)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if collection.UserID != userID {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		collectionID = uuid.NullUUID{UUID: parsedID, Valid: true}
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	bookmarks, err := cfg.queries.ListBookmarks(r.Context(), database.ListBookmarksParams{
+		UserID:          userID,
+		CollectionID:    collectionID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		MaxRows:         page.limit + 1,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	resp := chirpsPage{}
+	if len(bookmarks) > int(page.limit) {
+		bookmarks = bookmarks[:page.limit]
+		last := bookmarks[len(bookmarks)-1]
+		resp.NextCursor = pagination.EncodeCursor(last.BookmarkedAt, last.Chirp.ID)
+	}
+
+	chirps := []database.Chirp{}
+	for _, el := range bookmarks {
+		chirps = append(chirps, el.Chirp)
+	}
+
+	resp.Chirps, err = cfg.chirpsToJson(r.Context(), userID, chirps)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respData, err := json.Marshal(resp)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
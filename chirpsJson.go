@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/auth"
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func chirpToJson(chirp database.Chirp) returnJson {
+	respChirp := returnJson{
+		ID:         chirp.ID,
+		CreatedAt:  chirp.CreatedAt,
+		UpdatedAt:  chirp.UpdatedAt,
+		Body:       chirp.Body,
+		UserID:     chirp.UserID,
+		ThreadID:   chirp.ID,
+		Edited:     chirp.EditedAt.Valid,
+		Visibility: chirp.Visibility,
+	}
+
+	if chirp.InReplyTo.Valid {
+		respChirp.InReplyTo = &chirp.InReplyTo.UUID
+	}
+	if chirp.ThreadID.Valid {
+		respChirp.ThreadID = chirp.ThreadID.UUID
+	}
+	if chirp.QuoteOf.Valid {
+		respChirp.QuoteOf = &chirp.QuoteOf.UUID
+	}
+	if chirp.ScheduledAt.Valid {
+		respChirp.ScheduledAt = &chirp.ScheduledAt.Time
+	}
+
+	return respChirp
+}
+
+// viewerID returns the user behind the request's bearer token, or uuid.Nil for anonymous requests.
+func (cfg *apiConfig) viewerID(r *http.Request) uuid.UUID {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.Nil
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		return uuid.Nil
+	}
+
+	return userID
+}
+
+// nullUUID turns uuid.Nil into SQL NULL, which is how queries spell an anonymous viewer.
+func nullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
+
+// canSeeChirp reports whether viewerID may read chirp. viewerID may be uuid.Nil.
+func (cfg *apiConfig) canSeeChirp(ctx context.Context, viewerID uuid.UUID, chirp database.Chirp) (bool, error) {
+	if chirp.UserID == viewerID {
+		return true, nil
+	}
+
+	// anonymous viewers can't be blocked, so only the visibility level matters
+	if viewerID == uuid.Nil {
+		return chirp.Visibility == visibilityPublic, nil
+	}
+
+	return cfg.queries.IsChirpVisible(ctx, database.IsChirpVisibleParams{
+		ViewerID: nullUUID(viewerID),
+		ID:       chirp.ID,
+	})
+}
+
+// chirpsToJson converts chirps, fills in their details and embeds quoted chirps one level deep.
+// viewerID may be uuid.Nil, in which case the per-viewer fields are left out.
+func (cfg *apiConfig) chirpsToJson(ctx context.Context, viewerID uuid.UUID, chirps []database.Chirp) ([]returnJson, error) {
+	returnChirps := []returnJson{}
+	for _, el := range chirps {
+		returnChirps = append(returnChirps, chirpToJson(el))
+	}
+
+	err := cfg.fillChirpDetails(ctx, viewerID, returnChirps)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.embedQuotes(ctx, viewerID, returnChirps)
+	if err != nil {
+		return nil, err
+	}
+
+	return returnChirps, nil
+}
+
+// fillChirpDetails fills in the counters and entities that live in other rows.
+func (cfg *apiConfig) fillChirpDetails(ctx context.Context, viewerID uuid.UUID, returnChirps []returnJson) error {
+	chirpIDs := []uuid.UUID{}
+	for _, el := range returnChirps {
+		chirpIDs = append(chirpIDs, el.ID)
+	}
+
+	if len(chirpIDs) == 0 {
+		return nil
+	}
+
+	err := cfg.fillMentions(ctx, returnChirps)
+	if err != nil {
+		return err
+	}
+
+	err = cfg.fillMedia(ctx, returnChirps)
+	if err != nil {
+		return err
+	}
+
+	err = cfg.fillPolls(ctx, viewerID, returnChirps)
+	if err != nil {
+		return err
+	}
+
+	replyCounts, err := cfg.queries.CountReplies(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+
+	counts := map[uuid.UUID]int64{}
+	for _, el := range replyCounts {
+		counts[el.InReplyTo.UUID] = el.ReplyCount
+	}
+	for i := range returnChirps {
+		returnChirps[i].ReplyCount = counts[returnChirps[i].ID]
+	}
+
+	likeCounts, err := cfg.queries.CountLikes(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+
+	counts = map[uuid.UUID]int64{}
+	for _, el := range likeCounts {
+		counts[el.ChirpID] = el.LikeCount
+	}
+	for i := range returnChirps {
+		returnChirps[i].LikeCount = counts[returnChirps[i].ID]
+	}
+
+	rechirpCounts, err := cfg.queries.CountRechirps(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+
+	counts = map[uuid.UUID]int64{}
+	for _, el := range rechirpCounts {
+		counts[el.ChirpID] = el.RechirpCount
+	}
+	for i := range returnChirps {
+		returnChirps[i].RechirpCount = counts[returnChirps[i].ID]
+	}
+
+	quoteCounts, err := cfg.queries.CountQuotes(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+
+	counts = map[uuid.UUID]int64{}
+	for _, el := range quoteCounts {
+		counts[el.QuoteOf.UUID] = el.QuoteCount
+	}
+	for i := range returnChirps {
+		returnChirps[i].QuoteCount = counts[returnChirps[i].ID]
+	}
+
+	if viewerID == uuid.Nil {
+		return nil
+	}
+
+	likedIDs, err := cfg.queries.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+		UserID:   viewerID,
+		ChirpIds: chirpIDs,
+	})
+	if err != nil {
+		return err
+	}
+
+	liked := map[uuid.UUID]bool{}
+	for _, el := range likedIDs {
+		liked[el] = true
+	}
+	for i := range returnChirps {
+		likedByMe := liked[returnChirps[i].ID]
+		returnChirps[i].LikedByMe = &likedByMe
+	}
+
+	bookmarkedIDs, err := cfg.queries.GetBookmarkedChirpIDs(ctx, database.GetBookmarkedChirpIDsParams{
+		UserID:   viewerID,
+		ChirpIds: chirpIDs,
+	})
+	if err != nil {
+		return err
+	}
+
+	bookmarked := map[uuid.UUID]bool{}
+	for _, el := range bookmarkedIDs {
+		bookmarked[el] = true
+	}
+	for i := range returnChirps {
+		bookmarkedByMe := bookmarked[returnChirps[i].ID]
+		returnChirps[i].BookmarkedByMe = &bookmarkedByMe
+	}
+
+	return nil
+}
+
+// embedQuotes attaches the quoted chirps. A quote of a chirp that is gone gets a tombstone.
+func (cfg *apiConfig) embedQuotes(ctx context.Context, viewerID uuid.UUID, returnChirps []returnJson) error {
+	quotedIDs := []uuid.UUID{}
+	for _, el := range returnChirps {
+		if el.QuoteOf != nil {
+			quotedIDs = append(quotedIDs, *el.QuoteOf)
+		}
+	}
+
+	if len(quotedIDs) == 0 {
+		return nil
+	}
+
+	quotedChirps, err := cfg.queries.GetChirpsByIDs(ctx, database.GetChirpsByIDsParams{
+		ChirpIds: quotedIDs,
+		ViewerID: nullUUID(viewerID),
+	})
+	if err != nil {
+		return err
+	}
+
+	quoted := []returnJson{}
+	for _, el := range quotedChirps {
+		quoted = append(quoted, chirpToJson(el))
+	}
+
+	err = cfg.fillChirpDetails(ctx, viewerID, quoted)
+	if err != nil {
+		return err
+	}
+
+	byID := map[uuid.UUID]*returnJson{}
+	for i := range quoted {
+		byID[quoted[i].ID] = &quoted[i]
+	}
+
+	for i := range returnChirps {
+		if returnChirps[i].QuoteOf == nil {
+			continue
+		}
+
+		quotedID := *returnChirps[i].QuoteOf
+		returnChirps[i].Quote = &chirpRef{
+			ID:      quotedID,
+			Deleted: byID[quotedID] == nil,
+			Chirp:   byID[quotedID],
+		}
+	}
+
+	return nil
+}
@@ -2,28 +2,77 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"sort"
 	"time"
 
 	"github.com/YaroslavalsoraY/Chirpy/internal/auth"
 	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/timestamps"
 	"github.com/google/uuid"
 )
 
 type chirp struct {
-	Text string `json:"body"`
+	Text       string        `json:"body"`
+	InReplyTo  uuid.NullUUID `json:"in_reply_to"`
+	QuoteOf    uuid.NullUUID `json:"quote_of"`
+	MediaIDs   []uuid.UUID   `json:"media_ids"`
+	PublishAt  *time.Time    `json:"publish_at"`
+	Poll       *pollInput    `json:"poll"`
+	Visibility string        `json:"visibility"`
+}
+
+const (
+	visibilityPublic    = "public"
+	visibilityFollowers = "followers"
+	visibilityMentioned = "mentioned"
+)
+
+var chirpVisibilities = map[string]bool{
+	visibilityPublic:    true,
+	visibilityFollowers: true,
+	visibilityMentioned: true,
 }
 
 type returnJson struct {
-	InValid   bool      `json:"valid,omitempty"`
-	Err       string    `json:"error,omitempty"`
-	Body      string    `json:"body,omitempty"`
-	CreatedAt time.Time `json:"created_at,omitempty"`
-	UpdatedAt time.Time `json:"updated_at,omitempty"`
-	ID        uuid.UUID `json:"id,omitempty"`
-	UserID    uuid.UUID `json:"user_id,omitempty"`
+	InValid        bool          `json:"valid,omitempty"`
+	Err            string        `json:"error,omitempty"`
+	Body           string        `json:"body,omitempty"`
+	CreatedAt      time.Time     `json:"created_at,omitempty"`
+	UpdatedAt      time.Time     `json:"updated_at,omitempty"`
+	ID             uuid.UUID     `json:"id,omitempty"`
+	UserID         uuid.UUID     `json:"user_id,omitempty"`
+	InReplyTo      *uuid.UUID    `json:"in_reply_to,omitempty"`
+	ThreadID       uuid.UUID     `json:"thread_id"`
+	ReplyCount     int64         `json:"reply_count"`
+	Edited         bool          `json:"edited"`
+	LikeCount      int64         `json:"like_count"`
+	LikedByMe      *bool         `json:"liked_by_me,omitempty"`
+	BookmarkedByMe *bool         `json:"bookmarked_by_me,omitempty"`
+	Pinned         bool          `json:"pinned,omitempty"`
+	QuoteOf        *uuid.UUID    `json:"quote_of,omitempty"`
+	Quote          *chirpRef     `json:"quote,omitempty"`
+	QuoteCount     int64         `json:"quote_count"`
+	RechirpCount   int64         `json:"rechirp_count"`
+	RechirpedBy    *uuid.UUID    `json:"rechirped_by,omitempty"`
+	Mentions       []mentionJson `json:"mentions,omitempty"`
+	Media          []mediaJson   `json:"media,omitempty"`
+	ScheduledAt    *time.Time    `json:"scheduled_at,omitempty"`
+	Poll           *pollJson     `json:"poll,omitempty"`
+	Visibility     string        `json:"visibility,omitempty"`
+}
+
+// chirpRef points at another chirp, which may have been deleted since.
+type chirpRef struct {
+	ID      uuid.UUID   `json:"id"`
+	Deleted bool        `json:"deleted"`
+	Chirp   *returnJson `json:"chirp,omitempty"`
+}
+
+type chirpsPage struct {
+	Chirps     []returnJson `json:"chirps"`
+	NextCursor string       `json:"next_cursor,omitempty"`
 }
 
 func (cfg *apiConfig) HandlerCreateChirp(w http.ResponseWriter, r *http.Request) {
@@ -64,6 +113,35 @@ func (cfg *apiConfig) HandlerCreateChirp(w http.ResponseWriter, r *http.Request)
 		resp.InValid = true
 	}
 
+	if len(newChirp.MediaIDs) > maxMediaPerChirp {
+		resp.Err = "Too many media attachments"
+		resp.InValid = true
+	}
+
+	if newChirp.Visibility == "" {
+		newChirp.Visibility = visibilityPublic
+	}
+	if !chirpVisibilities[newChirp.Visibility] {
+		resp.Err = "visibility must be public, followers or mentioned"
+		resp.InValid = true
+	}
+
+	if newChirp.PublishAt != nil && !newChirp.PublishAt.After(time.Now()) {
+		resp.Err = "publish_at must be in the future"
+		resp.InValid = true
+	}
+
+	if newChirp.Poll != nil {
+		opensAt := time.Now().UTC()
+		if newChirp.PublishAt != nil {
+			opensAt = newChirp.PublishAt.UTC()
+		}
+		if reason := validatePoll(*newChirp.Poll, opensAt); reason != "" {
+			resp.Err = reason
+			resp.InValid = true
+		}
+	}
+
 	respBody, err := json.Marshal(resp)
 	if err != nil {
 		fmt.Println(err)
@@ -78,25 +156,154 @@ func (cfg *apiConfig) HandlerCreateChirp(w http.ResponseWriter, r *http.Request)
 	}
 
 	arg := database.InsertChirpParams{
-		Body:   newChirp.Text,
-		UserID: userID,
+		Body:       newChirp.Text,
+		UserID:     userID,
+		Visibility: newChirp.Visibility,
+	}
+
+	arg.ScheduledAt = timestamps.NullColumn(newChirp.PublishAt)
+
+	if newChirp.InReplyTo.Valid {
+		parent, err := cfg.queries.GetOneChirp(r.Context(), newChirp.InReplyTo.UUID)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if parent.DeletedAt.Valid || parent.ScheduledAt.Valid {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		visible, err := cfg.canSeeChirp(r.Context(), userID, parent)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if !visible {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		arg.InReplyTo = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		arg.ThreadID = parent.ThreadID
+		if !parent.ThreadID.Valid {
+			arg.ThreadID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		}
 	}
-	returnedChirp, err := cfg.queries.InsertChirp(r.Context(), arg)
+
+	if newChirp.QuoteOf.Valid {
+		quoted, err := cfg.queries.GetOneChirp(r.Context(), newChirp.QuoteOf.UUID)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// only public chirps can be quoted, a quote would show them to a wider audience
+		if quoted.DeletedAt.Valid || quoted.ScheduledAt.Valid || quoted.Visibility != visibilityPublic {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		visible, err := cfg.canSeeChirp(r.Context(), userID, quoted)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if !visible {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		arg.QuoteOf = uuid.NullUUID{UUID: quoted.ID, Valid: true}
+	}
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
 	if err != nil {
 		fmt.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
 
-	response := returnJson{
-		ID:        returnedChirp.ID,
-		CreatedAt: returnedChirp.CreatedAt,
-		UpdatedAt: returnedChirp.UpdatedAt,
-		Body:      returnedChirp.Body,
-		UserID:    returnedChirp.UserID,
+	returnedChirp, err := qtx.InsertChirp(r.Context(), arg)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 
-	respBody, err = json.Marshal(response)
+	notifications := []database.Notification{}
+
+	// scheduled chirps get their hashtags and mentions when the scheduler publishes them
+	if !returnedChirp.ScheduledAt.Valid {
+		err = saveHashtags(r.Context(), qtx, returnedChirp)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		mentions, err := saveMentions(r.Context(), qtx, returnedChirp)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		notifications, err = notifyChirp(r.Context(), qtx, returnedChirp, mentions)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if newChirp.Poll != nil {
+		err = savePoll(r.Context(), qtx, returnedChirp.ID, *newChirp.Poll)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err = attachMedia(r.Context(), qtx, userID, returnedChirp.ID, newChirp.MediaIDs)
+	if errors.Is(err, errMediaUnavailable) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if !returnedChirp.ScheduledAt.Valid {
+		cfg.publishChirpEvent(r.Context(), streamCreated, returnedChirp)
+		cfg.federateChirp(r.Context(), cfg.absoluteURL(r, ""), "Create", returnedChirp)
+	}
+	cfg.gateway.pushNotifications(notifications)
+
+	response, err := cfg.chirpsToJson(r.Context(), userID, []database.Chirp{returnedChirp})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respBody, err = json.Marshal(response[0])
 
 	w.WriteHeader(http.StatusCreated)
 	w.Write(respBody)
@@ -118,43 +325,73 @@ func (cfg *apiConfig) HandlerGetOneChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	respChirp := returnJson{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
+	viewerID := cfg.viewerID(r)
+
+	if chirp.ScheduledAt.Valid && chirp.UserID != viewerID {
+		w.WriteHeader(http.StatusNotFound)
+		return
 	}
 
-	respData, err := json.Marshal(respChirp)
+	visible, err := cfg.canSeeChirp(r.Context(), viewerID, chirp)
 	if err != nil {
 		fmt.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	w.Write(respData)
-}
+	// hidden chirps answer like missing ones so their existence doesn't leak
+	if !visible {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
 
-func (cfg *apiConfig) HandlerGetChirps(w http.ResponseWriter, r *http.Request) {
-	returnChirps := []returnJson{}
+	if chirp.DeletedAt.Valid {
+		respData, err := json.Marshal(chirpRef{ID: chirp.ID, Deleted: true})
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusGone)
+		w.Write(respData)
+		return
+	}
+
+	respChirps, err := cfg.chirpsToJson(r.Context(), viewerID, []database.Chirp{chirp})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 
-	chirps, err := cfg.queries.GetAllChirps(r.Context())
+	respData, err := json.Marshal(respChirps[0])
 	if err != nil {
 		fmt.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	authorID := uuid.Nil
+	w.Write(respData)
+}
+
+func (cfg *apiConfig) HandlerGetChirps(w http.ResponseWriter, r *http.Request) {
+	authorID := uuid.NullUUID{}
 	authorIDstring := r.URL.Query().Get("author_id")
 	if authorIDstring != "" {
-		authorID, err = uuid.Parse(authorIDstring)
+		parsedID, err := uuid.Parse(authorIDstring)
 		if err != nil {
 			fmt.Println(err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
+		authorID = uuid.NullUUID{UUID: parsedID, Valid: true}
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
 	}
 
 	sortingMethod := r.URL.Query().Get("sort")
@@ -162,24 +399,59 @@ func (cfg *apiConfig) HandlerGetChirps(w http.ResponseWriter, r *http.Request) {
 		sortingMethod = "asc"
 	}
 
-	for _, el := range chirps {
-		if authorID != uuid.Nil && el.UserID != authorID {
-			continue
-		}
-		returnChirps = append(returnChirps, returnJson{
-			ID:        el.ID,
-			CreatedAt: el.CreatedAt,
-			UpdatedAt: el.UpdatedAt,
-			Body:      el.Body,
-			UserID:    el.UserID,
-		})
+	viewerID := cfg.viewerID(r)
+
+	args := database.ListChirpsParams{
+		ViewerID:        nullUUID(viewerID),
+		AuthorID:        authorID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		MaxRows:         page.limit + 1,
 	}
 
+	var chirps []database.Chirp
 	if sortingMethod == "desc" {
-		sort.Slice(returnChirps, func(i, j int) bool { return returnChirps[i].CreatedAt.After(returnChirps[j].CreatedAt) })
+		chirps, err = cfg.queries.ListChirpsDesc(r.Context(), database.ListChirpsDescParams(args))
+	} else {
+		chirps, err = cfg.queries.ListChirps(r.Context(), args)
+	}
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	chirps, nextCursor := trimChirpsPage(chirps, page.limit)
+
+	returnChirps, err := cfg.chirpsToJson(r.Context(), viewerID, chirps)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if authorID.Valid {
+		returnChirps, err = cfg.withPinnedChirps(r.Context(), viewerID, authorID.UUID, page.cursor == nil, returnChirps)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
 	}
 
-	resp, err := json.Marshal(returnChirps)
+	// clients that don't page keep getting the bare array, the next page is in the Link header
+	var resp []byte
+	if r.URL.Query().Has("limit") || r.URL.Query().Has("cursor") {
+		resp, err = json.Marshal(chirpsPage{
+			Chirps:     returnChirps,
+			NextCursor: nextCursor,
+		})
+	} else {
+		if nextCursor != "" {
+			w.Header().Set("Link", nextPageLink(r, nextCursor))
+		}
+		resp, err = json.Marshal(returnChirps)
+	}
 	if err != nil {
 		fmt.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -189,6 +461,174 @@ func (cfg *apiConfig) HandlerGetChirps(w http.ResponseWriter, r *http.Request) {
 	w.Write(resp)
 }
 
+func (cfg *apiConfig) HandlerUpdateChirp(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	newChirp := chirp{}
+	err = decoder.Decode(&newChirp)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	newChirp.Text = badWordsReplace(newChirp.Text)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if len(newChirp.Text) > 140 {
+		respBody, err := json.Marshal(returnJson{
+			Err:     "Chirp is too long",
+			InValid: true,
+		})
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(respBody)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	oldChirp, err := qtx.GetChirpForUpdate(r.Context(), chirpID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if oldChirp.DeletedAt.Valid {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+
+	if oldChirp.UserID != userID {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	// nobody has seen a scheduled chirp yet, so its edits are not worth keeping
+	if !oldChirp.ScheduledAt.Valid {
+		err = qtx.InsertChirpRevision(r.Context(), database.InsertChirpRevisionParams{
+			CreatedAt: oldChirp.UpdatedAt,
+			ChirpID:   oldChirp.ID,
+			Body:      oldChirp.Body,
+		})
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	updatedChirp, err := qtx.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+		Body: newChirp.Text,
+		ID:   oldChirp.ID,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	notifications := []database.Notification{}
+	if !updatedChirp.ScheduledAt.Valid {
+		err = qtx.DeleteChirpHashtags(r.Context(), updatedChirp.ID)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		err = saveHashtags(r.Context(), qtx, updatedChirp)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		err = qtx.DeleteChirpMentions(r.Context(), updatedChirp.ID)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		mentions, err := saveMentions(r.Context(), qtx, updatedChirp)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		// users already notified about this chirp aren't notified again
+		notifications, err = notifyChirp(r.Context(), qtx, updatedChirp, mentions)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if !updatedChirp.ScheduledAt.Valid {
+		cfg.publishChirpEvent(r.Context(), streamEdited, updatedChirp)
+		cfg.federateChirp(r.Context(), cfg.absoluteURL(r, ""), "Update", updatedChirp)
+	}
+	cfg.gateway.pushNotifications(notifications)
+
+	respChirps, err := cfg.chirpsToJson(r.Context(), userID, []database.Chirp{updatedChirp})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respData, err := json.Marshal(respChirps[0])
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
+
 func (cfg *apiConfig) DeleteChirp(w http.ResponseWriter, r *http.Request) {
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
@@ -216,17 +656,51 @@ func (cfg *apiConfig) DeleteChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if chirp.DeletedAt.Valid {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+
 	if chirp.UserID != userID {
 		w.WriteHeader(http.StatusForbidden)
 		return
 	}
 
-	err = cfg.queries.DeleteChirp(r.Context(), chirpID)
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
 	if err != nil {
 		fmt.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	// the row stays around for replies, quotes and audits until the purge job removes it
+	err = qtx.SoftDeleteChirp(r.Context(), chirpID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = qtx.DeleteChirpPins(r.Context(), chirpID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if !chirp.ScheduledAt.Valid {
+		cfg.publishChirpEvent(r.Context(), streamDeleted, chirp)
+		cfg.federateChirp(r.Context(), cfg.absoluteURL(r, ""), "Delete", chirp)
+	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
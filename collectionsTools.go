@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/auth"
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxCollectionNameLen = 50
+
+type collection struct {
+	Name string `json:"name"`
+}
+
+type collectionJson struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+}
+
+func collectionToJson(c database.Collection) collectionJson {
+	return collectionJson{
+		ID:        c.ID,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		Name:      c.Name,
+	}
+}
+
+// collectionName trims the requested name and reports whether it is usable.
+func collectionName(c collection) (string, bool) {
+	name := strings.TrimSpace(c.Name)
+	return name, name != "" && utf8.RuneCountInString(name) <= maxCollectionNameLen
+}
+
+func (cfg *apiConfig) HandlerCreateCollection(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	newCollection := collection{}
+	err = decoder.Decode(&newCollection)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	name, ok := collectionName(newCollection)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	savedCollection, err := cfg.queries.InsertCollection(r.Context(), database.InsertCollectionParams{
+		UserID: userID,
+		Name:   name,
+	})
+	if isUniqueViolation(err) {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respData, err := json.Marshal(collectionToJson(savedCollection))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(respData)
+}
+
+func (cfg *apiConfig) HandlerGetCollections(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	collections, err := cfg.queries.ListCollections(r.Context(), userID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	returnCollections := []collectionJson{}
+	for _, el := range collections {
+		returnCollections = append(returnCollections, collectionToJson(el))
+	}
+
+	respData, err := json.Marshal(returnCollections)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
+
+func (cfg *apiConfig) HandlerRenameCollection(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	collectionID, err := uuid.Parse(r.PathValue("collectionID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	newCollection := collection{}
+	err = decoder.Decode(&newCollection)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	name, ok := collectionName(newCollection)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	savedCollection, err := cfg.queries.GetCollection(r.Context(), collectionID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	// someone else's collection answers like a missing one so its existence doesn't leak
+	if savedCollection.UserID != userID {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	savedCollection, err = cfg.queries.RenameCollection(r.Context(), database.RenameCollectionParams{
+		Name: name,
+		ID:   collectionID,
+	})
+	if isUniqueViolation(err) {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respData, err := json.Marshal(collectionToJson(savedCollection))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(respData)
+}
+
+// HandlerDeleteCollection removes a collection. Its bookmarks are kept, just no longer filed.
+func (cfg *apiConfig) HandlerDeleteCollection(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	collectionID, err := uuid.Parse(r.PathValue("collectionID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	savedCollection, err := cfg.queries.GetCollection(r.Context(), collectionID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if savedCollection.UserID != userID {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	err = cfg.queries.DeleteCollection(r.Context(), collectionID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/auth"
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const maxConversationMembers = 50
+
+type newConversation struct {
+	MemberIDs []uuid.UUID `json:"member_ids"`
+}
+
+type conversationJson struct {
+	ID          uuid.UUID    `json:"id"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	IsDirect    bool         `json:"is_direct"`
+	MemberIDs   []uuid.UUID  `json:"member_ids"`
+	LastMessage *messageJson `json:"last_message,omitempty"`
+	UnreadCount int64        `json:"unread_count"`
+}
+
+type conversationsPage struct {
+	Conversations []conversationJson `json:"conversations"`
+	NextCursor    string             `json:"next_cursor,omitempty"`
+}
+
+type unreadCount struct {
+	UnreadCount int64 `json:"unread_count"`
+}
+
+// conversationsToJson loads the members and latest message of each conversation.
+// unread maps conversation IDs to the caller's unread count.
+func (cfg *apiConfig) conversationsToJson(ctx context.Context, conversations []database.Conversation, unread map[uuid.UUID]int64) ([]conversationJson, error) {
+	returnConversations := []conversationJson{}
+	if len(conversations) == 0 {
+		return returnConversations, nil
+	}
+
+	conversationIDs := []uuid.UUID{}
+	for _, el := range conversations {
+		conversationIDs = append(conversationIDs, el.ID)
+	}
+
+	members, err := cfg.queries.GetConversationMembers(ctx, conversationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	membersByConversation := map[uuid.UUID][]database.ConversationMember{}
+	for _, el := range members {
+		membersByConversation[el.ConversationID] = append(membersByConversation[el.ConversationID], el)
+	}
+
+	lastMessages, err := cfg.queries.GetLastMessages(ctx, conversationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	lastByConversation := map[uuid.UUID]database.Message{}
+	for _, el := range lastMessages {
+		lastByConversation[el.ConversationID] = el
+	}
+
+	for _, el := range conversations {
+		respConversation := conversationJson{
+			ID:          el.ID,
+			CreatedAt:   el.CreatedAt,
+			UpdatedAt:   el.UpdatedAt,
+			IsDirect:    el.IsDirect,
+			MemberIDs:   []uuid.UUID{},
+			UnreadCount: unread[el.ID],
+		}
+
+		for _, member := range membersByConversation[el.ID] {
+			respConversation.MemberIDs = append(respConversation.MemberIDs, member.UserID)
+		}
+
+		if last, ok := lastByConversation[el.ID]; ok {
+			lastMessage := messageToJson(last, membersByConversation[el.ID])
+			respConversation.LastMessage = &lastMessage
+		}
+
+		returnConversations = append(returnConversations, respConversation)
+	}
+
+	return returnConversations, nil
+}
+
+// HandlerCreateConversation starts a conversation between the caller and member_ids.
+// Asking for a direct conversation that already exists returns that one instead.
+func (cfg *apiConfig) HandlerCreateConversation(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := newConversation{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	seen := map[uuid.UUID]bool{userID: true}
+	otherIDs := []uuid.UUID{}
+	for _, el := range params.MemberIDs {
+		if !seen[el] {
+			seen[el] = true
+			otherIDs = append(otherIDs, el)
+		}
+	}
+
+	if len(otherIDs) == 0 || len(otherIDs)+1 > maxConversationMembers {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	for _, el := range otherIDs {
+		_, err = cfg.queries.GetUserByID(r.Context(), el)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+	}
+
+	blocked, err := cfg.queries.HasBlockWithAny(r.Context(), database.HasBlockWithAnyParams{
+		UserID:   userID,
+		OtherIds: otherIDs,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if blocked {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	conversation, created, err := cfg.createConversation(r.Context(), userID, otherIDs)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+
+	returnConversations, err := cfg.conversationsToJson(r.Context(), []database.Conversation{conversation}, nil)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respData, err := json.Marshal(returnConversations[0])
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(respData)
+}
+
+// createConversation starts a conversation of userID and otherIDs. There is only one direct
+// conversation per pair, if it exists already it is returned and created is false.
+func (cfg *apiConfig) createConversation(ctx context.Context, userID uuid.UUID, otherIDs []uuid.UUID) (database.Conversation, bool, error) {
+	isDirect := len(otherIDs) == 1
+	directParams := database.FindDirectConversationParams{}
+	if isDirect {
+		directParams = database.FindDirectConversationParams{
+			UserID:  userID,
+			OtherID: otherIDs[0],
+		}
+
+		conversation, err := cfg.queries.FindDirectConversation(ctx, directParams)
+		if err == nil {
+			return conversation, false, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return database.Conversation{}, false, err
+		}
+	}
+
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Conversation{}, false, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	var conversation database.Conversation
+	if isDirect {
+		conversation, err = qtx.InsertDirectConversation(ctx, database.InsertDirectConversationParams{
+			CreatedBy: userID,
+			OtherID:   otherIDs[0],
+		})
+		// a concurrent request created the pair's conversation first
+		if errors.Is(err, sql.ErrNoRows) {
+			tx.Rollback()
+			conversation, err = cfg.queries.FindDirectConversation(ctx, directParams)
+			return conversation, false, err
+		}
+	} else {
+		conversation, err = qtx.InsertConversation(ctx, database.InsertConversationParams{
+			CreatedBy: userID,
+			IsDirect:  false,
+		})
+	}
+	if err != nil {
+		return database.Conversation{}, false, err
+	}
+
+	for _, el := range append([]uuid.UUID{userID}, otherIDs...) {
+		err = qtx.InsertConversationMember(ctx, database.InsertConversationMemberParams{
+			ConversationID: conversation.ID,
+			UserID:         el,
+		})
+		if err != nil {
+			return database.Conversation{}, false, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return database.Conversation{}, false, err
+	}
+
+	return conversation, true, nil
+}
+
+func (cfg *apiConfig) HandlerGetConversations(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	rows, err := cfg.queries.ListConversations(r.Context(), database.ListConversationsParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		MaxRows:         page.limit + 1,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	resp := conversationsPage{}
+	if len(rows) > int(page.limit) {
+		rows = rows[:page.limit]
+		last := rows[len(rows)-1]
+		resp.NextCursor = pagination.EncodeCursor(last.Conversation.UpdatedAt, last.Conversation.ID)
+	}
+
+	conversations := []database.Conversation{}
+	unread := map[uuid.UUID]int64{}
+	for _, el := range rows {
+		conversations = append(conversations, el.Conversation)
+		unread[el.Conversation.ID] = el.UnreadCount
+	}
+
+	resp.Conversations, err = cfg.conversationsToJson(r.Context(), conversations, unread)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respData, err := json.Marshal(resp)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
+
+func (cfg *apiConfig) HandlerGetUnreadCount(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	count, err := cfg.queries.CountUnreadMessages(r.Context(), userID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respData, err := json.Marshal(unreadCount{UnreadCount: count})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
+
+// HandlerMarkConversationRead marks everything in the conversation as read by the caller.
+func (cfg *apiConfig) HandlerMarkConversationRead(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.queries.GetConversationForMember(r.Context(), database.GetConversationForMemberParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	err = cfg.queries.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
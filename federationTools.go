@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/activitypub"
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/httpsig"
+	"github.com/google/uuid"
+)
+
+const (
+	deliveryInterval = 10 * time.Second
+	// a batch has to finish within the 5 minute lease of ClaimDueDeliveries, at 10s per post at most
+	deliveryBatchSize   = 20
+	maxDeliveryAttempts = 8
+)
+
+func actorURL(base string, userID uuid.UUID) string {
+	return base + "/users/" + userID.String()
+}
+
+func noteURL(base string, chirpID uuid.UUID) string {
+	return base + "/chirps/" + chirpID.String()
+}
+
+// localID returns the id at the end of one of our own IRIs, e.g. base/users/{id}.
+func localID(base, prefix, uri string) (uuid.UUID, bool) {
+	rest, ok := strings.CutPrefix(uri, base+prefix)
+	if !ok {
+		return uuid.UUID{}, false
+	}
+
+	id, err := uuid.Parse(rest)
+	if err != nil {
+		return uuid.UUID{}, false
+	}
+	return id, true
+}
+
+// actorKey returns the user's signing key pair, generating it the first time the user federates.
+func (cfg *apiConfig) actorKey(ctx context.Context, userID uuid.UUID) (database.ActorKey, error) {
+	key, err := cfg.queries.GetActorKey(ctx, userID)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.ActorKey{}, err
+	}
+
+	privatePEM, publicPEM, err := httpsig.GenerateKey()
+	if err != nil {
+		return database.ActorKey{}, err
+	}
+
+	// two requests may race here, ON CONFLICT keeps whichever key came first
+	err = cfg.queries.InsertActorKey(ctx, database.InsertActorKeyParams{
+		UserID:        userID,
+		PublicKeyPem:  publicPEM,
+		PrivateKeyPem: privatePEM,
+	})
+	if err != nil {
+		return database.ActorKey{}, err
+	}
+
+	return cfg.queries.GetActorKey(ctx, userID)
+}
+
+func noteContent(body string) string {
+	return "<p>" + strings.ReplaceAll(html.EscapeString(body), "\n", "<br>") + "</p>"
+}
+
+func chirpToNote(base string, chirp database.Chirp) activitypub.Note {
+	author := actorURL(base, chirp.UserID)
+	note := activitypub.Note{
+		ID:           noteURL(base, chirp.ID),
+		Type:         "Note",
+		AttributedTo: author,
+		Content:      noteContent(chirp.Body),
+		URL:          base + "/api/chirps/" + chirp.ID.String(),
+		To:           []string{activitypub.Public},
+		Cc:           []string{author + "/followers"},
+		Published:    chirp.CreatedAt,
+	}
+	if chirp.InReplyTo.Valid {
+		note.InReplyTo = noteURL(base, chirp.InReplyTo.UUID)
+	}
+	if chirp.EditedAt.Valid {
+		note.Updated = &chirp.EditedAt.Time
+	}
+	return note
+}
+
+// chirpActivity wraps a chirp in a Create, Update or Delete. A Delete only carries a Tombstone.
+func chirpActivity(base, kind string, chirp database.Chirp) (activitypub.Activity, error) {
+	note := chirpToNote(base, chirp)
+
+	var object any = note
+	id := note.ID + "#create"
+	switch kind {
+	case "Update":
+		id = note.ID + "#update-" + strconv.FormatInt(chirp.UpdatedAt.Unix(), 10)
+	case "Delete":
+		id = note.ID + "#delete"
+		object = map[string]string{"id": note.ID, "type": "Tombstone"}
+	}
+
+	rawObject, err := json.Marshal(object)
+	if err != nil {
+		return activitypub.Activity{}, err
+	}
+
+	return activitypub.Activity{
+		Context:   activitypub.Context,
+		ID:        id,
+		Type:      kind,
+		Actor:     note.AttributedTo,
+		Object:    rawObject,
+		To:        note.To,
+		Cc:        note.Cc,
+		Published: &chirp.UpdatedAt,
+	}, nil
+}
+
+// federateChirp queues a chirp change for the author's remote followers. The change is already
+// committed, delivery happens in deliverActivities. Only public chirps leave the server.
+func (cfg *apiConfig) federateChirp(ctx context.Context, base, kind string, chirp database.Chirp) {
+	if base == "" || chirp.Visibility != visibilityPublic {
+		return
+	}
+
+	inboxes, err := cfg.queries.GetFollowerInboxes(ctx, chirp.UserID)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if len(inboxes) == 0 {
+		return
+	}
+
+	activity, err := chirpActivity(base, kind, chirp)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	err = cfg.enqueueActivity(ctx, chirp.UserID, inboxes, activity)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+func (cfg *apiConfig) enqueueActivity(ctx context.Context, userID uuid.UUID, inboxes []string, activity activitypub.Activity) error {
+	rawActivity, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+
+	for _, el := range inboxes {
+		err = cfg.queries.InsertDelivery(ctx, database.InsertDeliveryParams{
+			UserID:   userID,
+			Inbox:    el,
+			Activity: rawActivity,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deliverActivities sends queued activities until ctx is cancelled. The queue lives in the
+// database, so deliveries survive a restart and failed ones are retried with backoff.
+func (cfg *apiConfig) deliverActivities(ctx context.Context) {
+	ticker := time.NewTicker(deliveryInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			delivered, err := cfg.deliverDueActivities(ctx)
+			if err != nil {
+				fmt.Println(err)
+				break
+			}
+			if delivered < deliveryBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverDueActivities sends one batch of due deliveries and returns how many it picked up.
+// Claiming a batch pushes its next attempt out by a lease and commits right away, so no lock is
+// held while posting, several instances never send the same delivery, and deliveries of a
+// worker that died are picked up again once the lease runs out.
+func (cfg *apiConfig) deliverDueActivities(ctx context.Context) (int, error) {
+	due, err := cfg.queries.ClaimDueDeliveries(ctx, deliveryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, el := range due {
+		// the rest of the batch is picked up again once its lease runs out
+		if ctx.Err() != nil {
+			return len(due), ctx.Err()
+		}
+
+		err = cfg.deliver(ctx, el)
+		if err == nil || el.Attempts+1 >= maxDeliveryAttempts {
+			if err != nil {
+				fmt.Println(err)
+			}
+			err = cfg.queries.DeleteDelivery(ctx, el.ID)
+			if err != nil {
+				fmt.Println(err)
+			}
+			continue
+		}
+
+		// the next attempt is 1, 2, 4 ... 64 minutes out
+		err = cfg.queries.RetryDelivery(ctx, database.RetryDeliveryParams{
+			LastError: err.Error(),
+			ID:        el.ID,
+		})
+		if err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	return len(due), nil
+}
+
+// deliver signs with the key of the activity's actor, so the key ID matches the URL the
+// activity was built with even if PUBLIC_URL changed since.
+func (cfg *apiConfig) deliver(ctx context.Context, delivery database.ApDelivery) error {
+	activity := activitypub.Activity{}
+	err := json.Unmarshal(delivery.Activity, &activity)
+	if err != nil {
+		return err
+	}
+
+	key, err := cfg.actorKey(ctx, delivery.UserID)
+	if err != nil {
+		return err
+	}
+
+	privateKey, err := httpsig.ParsePrivateKey(key.PrivateKeyPem)
+	if err != nil {
+		return err
+	}
+
+	return cfg.apClient.Deliver(ctx, delivery.Inbox, delivery.Activity, activity.Actor+"#main-key", privateKey)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/feed"
+	"github.com/google/uuid"
+)
+
+const feedLimit = 50
+
+// absoluteURL prefixes path with PUBLIC_URL, or with the scheme and host the request came in on.
+func (cfg *apiConfig) absoluteURL(r *http.Request, path string) string {
+	if cfg.publicURL != "" {
+		return cfg.publicURL + path
+	}
+
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + path
+}
+
+// feedEntries only gets public, published, undeleted chirps, the queries see an anonymous viewer.
+func (cfg *apiConfig) feedEntries(r *http.Request, chirps []database.Chirp) ([]feed.Entry, error) {
+	authorIDs := []uuid.UUID{}
+	for _, el := range chirps {
+		authorIDs = append(authorIDs, el.UserID)
+	}
+
+	authors, err := cfg.queries.GetUsersByIDs(r.Context(), authorIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	authorNames := map[uuid.UUID]string{}
+	for _, el := range authors {
+		authorNames[el.ID] = feedAuthor(el.ID, el.Handle, el.DisplayName)
+	}
+
+	entries := []feed.Entry{}
+	for _, el := range chirps {
+		entries = append(entries, feed.Entry{
+			ID:        "urn:uuid:" + el.ID.String(),
+			Title:     feed.Title(el.Body),
+			Link:      cfg.absoluteURL(r, "/api/chirps/"+el.ID.String()),
+			Author:    authorNames[el.UserID],
+			Content:   el.Body,
+			Published: el.CreatedAt,
+			Updated:   el.UpdatedAt,
+		})
+	}
+
+	return entries, nil
+}
+
+func feedAuthor(id uuid.UUID, handle sql.NullString, displayName string) string {
+	if handle.Valid {
+		return "@" + handle.String
+	}
+	if displayName != "" {
+		return displayName
+	}
+	return id.String()
+}
+
+// serveFeed renders f as Atom or RSS, depending on the requested file name. The ETag is a hash
+// of the body, so it changes when a chirp drops out even if nothing newer came in.
+func serveFeed(w http.ResponseWriter, r *http.Request, f feed.Feed) {
+	var body []byte
+	var err error
+	if strings.HasSuffix(r.URL.Path, ".rss") {
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		body, err = feed.RSS(f)
+	} else {
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		body, err = feed.Atom(f)
+	}
+	if err != nil {
+		fmt.Println(err)
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	// ServeContent answers If-None-Match and If-Modified-Since with 304
+	http.ServeContent(w, r, "", f.Updated, bytes.NewReader(body))
+}
+
+func (cfg *apiConfig) HandlerUserFeed(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	user, err := cfg.queries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	chirps, err := cfg.queries.ListChirpsDesc(r.Context(), database.ListChirpsDescParams{
+		AuthorID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		MaxRows:  feedLimit,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// deletions bump updated_at too, so this moves when a chirp drops out of the feed
+	updatedAt, err := cfg.queries.GetUserFeedUpdatedAt(r.Context(), user.ID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if updatedAt.Before(user.CreatedAt.Time) {
+		updatedAt = user.CreatedAt.Time
+	}
+
+	entries, err := cfg.feedEntries(r, chirps)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	link := cfg.absoluteURL(r, "/api/chirps?author_id="+user.ID.String())
+	if user.Handle.Valid {
+		link = cfg.absoluteURL(r, "/api/users/"+user.Handle.String)
+	}
+
+	serveFeed(w, r, feed.Feed{
+		ID:          cfg.absoluteURL(r, "/users/"+user.ID.String()+"/feed.atom"),
+		Title:       feedAuthor(user.ID, user.Handle, user.DisplayName) + " on Chirpy",
+		Description: user.Bio,
+		Link:        link,
+		SelfLink:    cfg.absoluteURL(r, r.URL.Path),
+		Updated:     updatedAt,
+		Entries:     entries,
+	})
+}
+
+func (cfg *apiConfig) HandlerHashtagFeed(w http.ResponseWriter, r *http.Request) {
+	tag := strings.ToLower(strings.TrimPrefix(r.PathValue("tag"), "#"))
+	if tag == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	rows, err := cfg.queries.ListHashtagChirps(r.Context(), database.ListHashtagChirpsParams{
+		Tag:     tag,
+		MaxRows: feedLimit,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	chirps := []database.Chirp{}
+	for _, el := range rows {
+		chirps = append(chirps, el.Chirp)
+	}
+
+	updatedAt, err := cfg.queries.GetHashtagFeedUpdatedAt(r.Context(), tag)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	entries, err := cfg.feedEntries(r, chirps)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	serveFeed(w, r, feed.Feed{
+		ID:          cfg.absoluteURL(r, "/hashtags/"+tag+"/feed.atom"),
+		Title:       "#" + tag + " on Chirpy",
+		Description: "Public chirps tagged #" + tag,
+		Link:        cfg.absoluteURL(r, "/api/hashtags/"+tag+"/chirps"),
+		SelfLink:    cfg.absoluteURL(r, r.URL.Path),
+		Updated:     updatedAt,
+		Entries:     entries,
+	})
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/auth"
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+type followUser struct {
+	ID         uuid.UUID `json:"id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type followsPage struct {
+	Users      []followUser `json:"users"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+func (cfg *apiConfig) HandlerFollow(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if followeeID == userID {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.queries.GetUserByID(r.Context(), followeeID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	blocked, err := cfg.queries.IsBlockedEitherWay(r.Context(), database.IsBlockedEitherWayParams{
+		UserID:  userID,
+		OtherID: followeeID,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if blocked {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	followed, err := cfg.queries.FollowUser(r.Context(), database.FollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// following again changes nothing and notifies nobody
+	if followed == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	notifications, err := notify(r.Context(), cfg.queries, followeeID, userID, notificationFollow, uuid.NullUUID{})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	cfg.gateway.pushNotifications(notifications)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) HandlerUnfollow(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = cfg.queries.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) HandlerGetFollowers(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	followers, err := cfg.queries.ListFollowers(r.Context(), database.ListFollowersParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		MaxRows:         page.limit + 1,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	resp := followsPage{
+		Users: []followUser{},
+	}
+	for _, el := range followers {
+		resp.Users = append(resp.Users, followUser{
+			ID:         el.UserID,
+			FollowedAt: el.CreatedAt,
+		})
+	}
+	resp.Users, resp.NextCursor = trimFollowsPage(resp.Users, page.limit)
+
+	respData, err := json.Marshal(resp)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
+
+func (cfg *apiConfig) HandlerGetFollowing(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	following, err := cfg.queries.ListFollowing(r.Context(), database.ListFollowingParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		MaxRows:         page.limit + 1,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	resp := followsPage{
+		Users: []followUser{},
+	}
+	for _, el := range following {
+		resp.Users = append(resp.Users, followUser{
+			ID:         el.UserID,
+			FollowedAt: el.CreatedAt,
+		})
+	}
+	resp.Users, resp.NextCursor = trimFollowsPage(resp.Users, page.limit)
+
+	respData, err := json.Marshal(resp)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
+
+func (cfg *apiConfig) HandlerTimeline(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	entries, err := cfg.queries.ListTimeline(r.Context(), database.ListTimelineParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		MaxRows:         page.limit + 1,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	resp := chirpsPage{}
+	if len(entries) > int(page.limit) {
+		entries = entries[:page.limit]
+		last := entries[len(entries)-1]
+		resp.NextCursor = pagination.EncodeCursor(last.EntryAt, last.EntryID)
+	}
+
+	chirps := []database.Chirp{}
+	for _, el := range entries {
+		chirps = append(chirps, el.Chirp)
+	}
+
+	resp.Chirps, err = cfg.chirpsToJson(r.Context(), userID, chirps)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	for i, el := range entries {
+		if el.RechirpedBy.Valid {
+			resp.Chirps[i].RechirpedBy = &entries[i].RechirpedBy.UUID
+		}
+	}
+
+	respData, err := json.Marshal(resp)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
+
+func trimFollowsPage(users []followUser, limit int32) ([]followUser, string) {
+	if len(users) <= int(limit) {
+		return users, ""
+	}
+
+	users = users[:limit]
+	last := users[len(users)-1]
+	return users, pagination.EncodeCursor(last.FollowedAt, last.ID)
+}
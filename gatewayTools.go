@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/activitypub"
+	"github.com/YaroslavalsoraY/Chirpy/internal/auth"
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/websocket"
+	"github.com/google/uuid"
+)
+
+const (
+	gatewayNotification = "notification"
+	gatewayMessage      = "message"
+	gatewayTyping       = "typing"
+	gatewayError        = "error"
+)
+
+const (
+	gatewayPingInterval = 30 * time.Second
+	// gatewayPongWait is how long a connection may stay silent, it has to cover one ping round trip.
+	gatewayPongWait  = 60 * time.Second
+	gatewayWriteWait = 10 * time.Second
+	// gatewaySendBuffer is how many events a client may fall behind before it is disconnected.
+	gatewaySendBuffer = 64
+)
+
+// gatewayEvent is the envelope of everything the gateway sends.
+type gatewayEvent struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// gatewayCommand is what clients send. Typing indicators are the only command so far.
+type gatewayCommand struct {
+	Type           string    `json:"type"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+}
+
+type typingJson struct {
+	ConversationID uuid.UUID `json:"conversation_id"`
+	UserID         uuid.UUID `json:"user_id"`
+}
+
+type gatewayClient struct {
+	userID    uuid.UUID
+	conn      *websocket.Conn
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *gatewayClient) close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close(code, reason)
+	})
+}
+
+// gateway keeps the open WebSocket connections of every user, a user may have several devices.
+type gateway struct {
+	mu      sync.Mutex
+	clients map[uuid.UUID]map[*gatewayClient]struct{}
+}
+
+func newGateway() *gateway {
+	return &gateway{
+		clients: map[uuid.UUID]map[*gatewayClient]struct{}{},
+	}
+}
+
+func (g *gateway) register(c *gatewayClient) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.clients[c.userID] == nil {
+		g.clients[c.userID] = map[*gatewayClient]struct{}{}
+	}
+	g.clients[c.userID][c] = struct{}{}
+}
+
+func (g *gateway) unregister(c *gatewayClient) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.clients[c.userID], c)
+	if len(g.clients[c.userID]) == 0 {
+		delete(g.clients, c.userID)
+	}
+}
+
+// send queues an event for every connection of userIDs. It never blocks: a client whose
+// queue is full is disconnected and has to reconnect and refetch what it missed.
+func (g *gateway) send(userIDs []uuid.UUID, eventType string, data any) {
+	event, err := json.Marshal(gatewayEvent{Type: eventType, Data: data})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, userID := range userIDs {
+		for c := range g.clients[userID] {
+			select {
+			case c.send <- event:
+			default:
+				delete(g.clients[userID], c)
+				go c.close(websocket.CloseTryAgainLater, "Too slow")
+			}
+		}
+	}
+}
+
+func (g *gateway) pushNotifications(notifications []database.Notification) {
+	for _, el := range notifications {
+		g.send([]uuid.UUID{el.UserID}, gatewayNotification, notificationToJson(el))
+	}
+}
+
+// shutdown tells every client the server is going away. It runs when the HTTP server shuts down,
+// which doesn't know about hijacked connections.
+func (g *gateway) shutdown() {
+	g.mu.Lock()
+	clients := []*gatewayClient{}
+	for _, userClients := range g.clients {
+		for c := range userClients {
+			clients = append(clients, c)
+		}
+	}
+	g.clients = map[uuid.UUID]map[*gatewayClient]struct{}{}
+	g.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, el := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			el.close(websocket.CloseGoingAway, "Server shutting down")
+		}()
+	}
+	wg.Wait()
+}
+
+// sendError answers a bad command on the connection that sent it, the user's other devices
+// didn't cause it.
+func (c *gatewayClient) sendError(message string) {
+	event, err := json.Marshal(gatewayEvent{Type: gatewayError, Data: message})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	err = c.conn.WriteMessageTimeout(websocket.TextMessage, event, gatewayWriteWait)
+	if err != nil {
+		c.close(websocket.CloseGoingAway, "")
+	}
+}
+
+// writeLoop writes the queued events and the pings. Conn serializes them with sendError.
+func (c *gatewayClient) writeLoop() {
+	ticker := time.NewTicker(gatewayPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case event := <-c.send:
+			err := c.conn.WriteMessageTimeout(websocket.TextMessage, event, gatewayWriteWait)
+			if err != nil {
+				c.close(websocket.CloseGoingAway, "")
+				return
+			}
+		case <-ticker.C:
+			err := c.conn.WriteMessageTimeout(websocket.PingMessage, nil, gatewayWriteWait)
+			if err != nil {
+				c.close(websocket.CloseGoingAway, "")
+				return
+			}
+		}
+	}
+}
+
+// HandlerGateway upgrades to a WebSocket that carries notifications, direct messages and typing indicators.
+// Browsers can't set headers on a WebSocket, so the JWT may come as ?access_token instead.
+// Any page could use such a token, so browsers are only let in from allowed origins.
+func (cfg *apiConfig) HandlerGateway(w http.ResponseWriter, r *http.Request) {
+	if !cfg.allowedOrigin(r) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		token = r.URL.Query().Get("access_token")
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	client := &gatewayClient{
+		userID: userID,
+		conn:   conn,
+		send:   make(chan []byte, gatewaySendBuffer),
+		done:   make(chan struct{}),
+	}
+
+	cfg.gateway.register(client)
+	defer cfg.gateway.unregister(client)
+	defer client.close(websocket.CloseNormal, "")
+
+	go client.writeLoop()
+
+	conn.SetReadDeadline(time.Now().Add(gatewayPongWait))
+	conn.PongHandler = func() {
+		conn.SetReadDeadline(time.Now().Add(gatewayPongWait))
+	}
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(gatewayPongWait))
+
+		command := gatewayCommand{}
+		err = json.Unmarshal(data, &command)
+		if err != nil || command.Type != gatewayTyping {
+			client.sendError("Unknown command")
+			continue
+		}
+
+		err = cfg.sendTyping(r.Context(), userID, command.ConversationID)
+		if err != nil {
+			fmt.Println(err)
+			client.sendError("Conversation not available")
+		}
+	}
+}
+
+// allowedOrigin accepts clients without an Origin, which are not browsers, pages of this server
+// itself and the origins in GATEWAY_ORIGINS.
+func (cfg *apiConfig) allowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	originURL, err := url.Parse(origin)
+	if err == nil && strings.EqualFold(originURL.Host, r.Host) {
+		return true
+	}
+
+	if cfg.publicURL != "" && activitypub.SameOrigin(origin, cfg.publicURL) {
+		return true
+	}
+
+	for _, el := range cfg.gatewayOrigins {
+		if activitypub.SameOrigin(origin, strings.TrimSpace(el)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sendTyping tells the other members of the conversation that userID is typing.
+func (cfg *apiConfig) sendTyping(ctx context.Context, userID uuid.UUID, conversationID uuid.UUID) error {
+	conversation, err := cfg.queries.GetConversationForMember(ctx, database.GetConversationForMemberParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	members, err := cfg.queries.GetConversationMembers(ctx, []uuid.UUID{conversationID})
+	if err != nil {
+		return err
+	}
+
+	blocked, err := cfg.blockedInConversation(ctx, userID, conversation, members)
+	if err != nil || blocked {
+		return err
+	}
+
+	otherIDs := []uuid.UUID{}
+	for _, el := range members {
+		if el.UserID != userID {
+			otherIDs = append(otherIDs, el.UserID)
+		}
+	}
+
+	cfg.gateway.send(otherIDs, gatewayTyping, typingJson{
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/entities"
+	"github.com/YaroslavalsoraY/Chirpy/internal/timestamps"
+)
+
+const trendingLimit = 10
+
+var trendingWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+type trendingTag struct {
+	Tag        string `json:"tag"`
+	ChirpCount int64  `json:"chirp_count"`
+}
+
+type trendingResp struct {
+	Window string        `json:"window"`
+	Tags   []trendingTag `json:"tags"`
+}
+
+// saveHashtags indexes the chirp's #tags. They keep the chirp's creation time so edits don't bump trending.
+func saveHashtags(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	for _, tag := range entities.Hashtags(chirp.Body) {
+		err := q.InsertChirpHashtag(ctx, database.InsertChirpHashtagParams{
+			ChirpID:   chirp.ID,
+			Tag:       tag,
+			CreatedAt: chirp.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cfg *apiConfig) HandlerGetHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	tag := strings.ToLower(strings.TrimPrefix(r.PathValue("tag"), "#"))
+	if tag == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+
+	rows, err := cfg.queries.ListHashtagChirps(r.Context(), database.ListHashtagChirpsParams{
+		Tag:             tag,
+		ViewerID:        nullUUID(viewerID),
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		MaxRows:         page.limit + 1,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	chirps := []database.Chirp{}
+	for _, el := range rows {
+		chirps = append(chirps, el.Chirp)
+	}
+
+	resp := chirpsPage{}
+	chirps, resp.NextCursor = trimChirpsPage(chirps, page.limit)
+	resp.Chirps, err = cfg.chirpsToJson(r.Context(), viewerID, chirps)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respData, err := json.Marshal(resp)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
+
+func (cfg *apiConfig) HandlerTrending(w http.ResponseWriter, r *http.Request) {
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = "24h"
+	}
+
+	windowLength, ok := trendingWindows[window]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	tags, err := cfg.queries.GetTrendingHashtags(r.Context(), database.GetTrendingHashtagsParams{
+		Since:   timestamps.Column(time.Now().Add(-windowLength)),
+		MaxRows: trendingLimit,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	resp := trendingResp{
+		Window: window,
+		Tags:   []trendingTag{},
+	}
+	for _, el := range tags {
+		resp.Tags = append(resp.Tags, trendingTag{
+			Tag:        el.Tag,
+			ChirpCount: el.ChirpCount,
+		})
+	}
+
+	respData, err := json.Marshal(resp)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
@@ -0,0 +1,173 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	ContentType = "application/activity+json"
+	// LDContentType is the other media type servers send and accept for ActivityStreams.
+	LDContentType = `application/ld+json; profile="https://www.w3.org/ns/activitystreams"`
+
+	Context         = "https://www.w3.org/ns/activitystreams"
+	SecurityContext = "https://w3id.org/security/v1"
+	// Public addresses an object to everyone.
+	Public = "https://www.w3.org/ns/activitystreams#Public"
+)
+
+type PublicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+type Endpoints struct {
+	SharedInbox string `json:"sharedInbox,omitempty"`
+}
+
+type Image struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type Actor struct {
+	Context           any        `json:"@context,omitempty"`
+	ID                string     `json:"id"`
+	Type              string     `json:"type"`
+	PreferredUsername string     `json:"preferredUsername"`
+	Name              string     `json:"name,omitempty"`
+	Summary           string     `json:"summary,omitempty"`
+	URL               string     `json:"url,omitempty"`
+	Icon              *Image     `json:"icon,omitempty"`
+	Inbox             string     `json:"inbox"`
+	Outbox            string     `json:"outbox,omitempty"`
+	Followers         string     `json:"followers,omitempty"`
+	Endpoints         *Endpoints `json:"endpoints,omitempty"`
+	PublicKey         PublicKey  `json:"publicKey"`
+	Published         *time.Time `json:"published,omitempty"`
+}
+
+// SharedInbox is where deliveries to this actor go, several followers on one server share it.
+func (a Actor) SharedInbox() string {
+	if a.Endpoints != nil && a.Endpoints.SharedInbox != "" {
+		return a.Endpoints.SharedInbox
+	}
+	return a.Inbox
+}
+
+type Note struct {
+	Context      any        `json:"@context,omitempty"`
+	ID           string     `json:"id"`
+	Type         string     `json:"type"`
+	AttributedTo string     `json:"attributedTo"`
+	Content      string     `json:"content"`
+	InReplyTo    string     `json:"inReplyTo,omitempty"`
+	URL          string     `json:"url,omitempty"`
+	To           []string   `json:"to,omitempty"`
+	Cc           []string   `json:"cc,omitempty"`
+	Published    time.Time  `json:"published"`
+	Updated      *time.Time `json:"updated,omitempty"`
+}
+
+// Activity keeps Object raw, it is either an IRI or an embedded object.
+type Activity struct {
+	Context   any             `json:"@context,omitempty"`
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Actor     string          `json:"actor"`
+	Object    json.RawMessage `json:"object"`
+	To        []string        `json:"to,omitempty"`
+	Cc        []string        `json:"cc,omitempty"`
+	Published *time.Time      `json:"published,omitempty"`
+}
+
+// object is the part of an embedded object every handler looks at.
+type object struct {
+	ID     string          `json:"id"`
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// ObjectID returns the IRI of the activity's object, embedded or not.
+func (a Activity) ObjectID() string {
+	return objectID(a.Object)
+}
+
+// ObjectType returns the type of an embedded object, or "" for a bare IRI.
+func (a Activity) ObjectType() string {
+	embedded := object{}
+	if json.Unmarshal(a.Object, &embedded) != nil {
+		return ""
+	}
+	return embedded.Type
+}
+
+// InnerObjectID is the object of the embedded object, e.g. the followed actor of an undone Follow.
+func (a Activity) InnerObjectID() string {
+	embedded := object{}
+	if json.Unmarshal(a.Object, &embedded) != nil {
+		return ""
+	}
+	return objectID(embedded.Object)
+}
+
+func objectID(raw json.RawMessage) string {
+	var id string
+	if json.Unmarshal(raw, &id) == nil {
+		return id
+	}
+
+	embedded := object{}
+	if json.Unmarshal(raw, &embedded) == nil {
+		return embedded.ID
+	}
+	return ""
+}
+
+// SameOrigin reports whether two IRIs have the same scheme and host. A server only speaks for
+// the IRIs on its own origin.
+func SameOrigin(a, b string) bool {
+	aURL, err := url.Parse(a)
+	if err != nil || aURL.Host == "" {
+		return false
+	}
+	bURL, err := url.Parse(b)
+	if err != nil || bURL.Host == "" {
+		return false
+	}
+	return strings.EqualFold(aURL.Scheme, bURL.Scheme) && strings.EqualFold(aURL.Host, bURL.Host)
+}
+
+type OrderedCollection struct {
+	Context      any    `json:"@context,omitempty"`
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	TotalItems   int64  `json:"totalItems"`
+	First        string `json:"first,omitempty"`
+	OrderedItems []any  `json:"orderedItems,omitempty"`
+}
+
+type OrderedCollectionPage struct {
+	Context      any    `json:"@context,omitempty"`
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	PartOf       string `json:"partOf"`
+	Next         string `json:"next,omitempty"`
+	OrderedItems []any  `json:"orderedItems"`
+}
+
+type Link struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type,omitempty"`
+	Href string `json:"href"`
+}
+
+// WebFinger is a JSON Resource Descriptor, RFC 7033.
+type WebFinger struct {
+	Subject string   `json:"subject"`
+	Aliases []string `json:"aliases,omitempty"`
+	Links   []Link   `json:"links"`
+}
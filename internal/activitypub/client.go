@@ -0,0 +1,196 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/httpsig"
+)
+
+const (
+	// MaxBodySize caps documents read from and accepted by other servers.
+	MaxBodySize = 1 << 20
+
+	maxRedirects = 5
+
+	// actor fetches are triggered by unauthenticated requests, so they are capped
+	fetchWindow       = time.Minute
+	maxFetchesPerHost = 10
+	maxFetches        = 100
+)
+
+var (
+	ErrInsecureURL      = errors.New("Only https URLs are allowed")
+	ErrForbiddenAddress = errors.New("Address is not publicly routable")
+	ErrRateLimited      = errors.New("Too many fetches")
+)
+
+// Client talks to other servers. URLs come from other servers too, so it only speaks https and
+// refuses to connect to loopback, private and link-local addresses.
+type Client struct {
+	HTTP    *http.Client
+	fetches *limiter
+}
+
+func NewClient() *Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		// Control runs after DNS resolution, for every address tried and on every redirect
+		Control: checkAddress,
+	}
+
+	return &Client{
+		HTTP: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: 5 * time.Second,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			CheckRedirect: checkRedirect,
+		},
+		fetches: newLimiter(fetchWindow, maxFetchesPerHost, maxFetches),
+	}
+}
+
+// nonPublic lists ranges netip does not flag itself: "this network", carrier-grade NAT,
+// IETF protocol assignments, benchmarking and NAT64.
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+func checkAddress(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+	}
+	for _, el := range nonPublic {
+		if el.Contains(ip) {
+			return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+		}
+	}
+
+	return nil
+}
+
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("Stopped after %d redirects", maxRedirects)
+	}
+	return checkURL(req.URL)
+}
+
+func checkURL(u *url.URL) error {
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%w: %s", ErrInsecureURL, u.Redacted())
+	}
+	return nil
+}
+
+// FetchActor dereferences an actor IRI. A key ID like https://host/users/alice#main-key works too,
+// the fragment never reaches the server. The actor and its key have to live on the origin the
+// document came from, otherwise any server could publish a key for someone else's actor.
+func (c *Client) FetchActor(ctx context.Context, uri string) (Actor, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return Actor{}, err
+	}
+
+	err = checkURL(req.URL)
+	if err != nil {
+		return Actor{}, err
+	}
+
+	if c.fetches != nil && !c.fetches.allow(strings.ToLower(req.URL.Host)) {
+		return Actor{}, fmt.Errorf("%w from %s", ErrRateLimited, req.URL.Host)
+	}
+	req.Header.Set("Accept", ContentType+", "+LDContentType)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return Actor{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Actor{}, fmt.Errorf("Fetching %s: %s", uri, resp.Status)
+	}
+
+	actor := Actor{}
+	err = json.NewDecoder(io.LimitReader(resp.Body, MaxBodySize)).Decode(&actor)
+	if err != nil {
+		return Actor{}, err
+	}
+
+	if actor.ID == "" || actor.Inbox == "" || actor.PublicKey.PublicKeyPem == "" {
+		return Actor{}, fmt.Errorf("Fetching %s: incomplete actor", uri)
+	}
+
+	// resp.Request is the last request made, after any redirects
+	fetchedFrom := resp.Request.URL.String()
+	if !SameOrigin(uri, fetchedFrom) || !SameOrigin(uri, actor.ID) || !SameOrigin(uri, actor.PublicKey.ID) {
+		return Actor{}, fmt.Errorf("Fetching %s: actor %s is not from the same origin", uri, actor.ID)
+	}
+
+	return actor, nil
+}
+
+// Deliver posts an activity to an inbox, signed with the sending actor's key.
+func (c *Client) Deliver(ctx context.Context, inbox string, activity []byte, keyID string, key *rsa.PrivateKey) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox, bytes.NewReader(activity))
+	if err != nil {
+		return err
+	}
+
+	err = checkURL(req.URL)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set("Accept", ContentType)
+
+	err = httpsig.Sign(req, keyID, key, activity)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, MaxBodySize))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Delivering to %s: %s", inbox, resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,252 @@
+package activitypub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/httpsig"
+)
+
+// testClient is NewClient with a transport that trusts the stand-in's certificate and, unlike the
+// real one, connects to loopback.
+func testClient(server *httptest.Server) *Client {
+	client := NewClient()
+	client.HTTP.Transport = server.Client().Transport
+	return client
+}
+
+// standIn is a tiny remote server: it serves one actor and verifies what lands in its inbox.
+func standIn(t *testing.T, publicPEM string, received chan<- Activity) *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewTLSServer(mux)
+
+	mux.HandleFunc("GET /users/alice", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		json.NewEncoder(w).Encode(Actor{
+			ID:                server.URL + "/users/alice",
+			Type:              "Person",
+			PreferredUsername: "alice",
+			Inbox:             server.URL + "/users/alice/inbox",
+			Endpoints:         &Endpoints{SharedInbox: server.URL + "/inbox"},
+			PublicKey: PublicKey{
+				ID:           server.URL + "/users/alice#main-key",
+				Owner:        server.URL + "/users/alice",
+				PublicKeyPem: publicPEM,
+			},
+		})
+	})
+
+	mux.HandleFunc("POST /inbox", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sig, err := httpsig.ParseSignature(r)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		key, _ := httpsig.ParsePublicKey(publicPEM)
+		err = httpsig.Verify(r, body, sig, key)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		activity := Activity{}
+		json.Unmarshal(body, &activity)
+		received <- activity
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	return server
+}
+
+func TestFetchActor(t *testing.T) {
+	_, publicPEM, _ := httpsig.GenerateKey()
+	server := standIn(t, publicPEM, nil)
+	defer server.Close()
+
+	actor, err := testClient(server).FetchActor(context.Background(), server.URL+"/users/alice#main-key")
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+	if actor.PreferredUsername != "alice" || actor.SharedInbox() != server.URL+"/inbox" {
+		t.Errorf("invalid actor: %+v", actor)
+	}
+
+	_, err = testClient(server).FetchActor(context.Background(), server.URL+"/users/bob")
+	if err == nil {
+		t.Errorf("missing actor was fetched")
+	}
+}
+
+func TestFetchActorOrigin(t *testing.T) {
+	_, publicPEM, _ := httpsig.GenerateKey()
+	victim := standIn(t, publicPEM, nil)
+	defer victim.Close()
+
+	// evil serves victim's actor with a key of its own
+	evil := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		json.NewEncoder(w).Encode(Actor{
+			ID:    victim.URL + "/users/alice",
+			Type:  "Person",
+			Inbox: victim.URL + "/users/alice/inbox",
+			PublicKey: PublicKey{
+				ID:           "https://" + r.Host + "/k#main-key",
+				Owner:        victim.URL + "/users/alice",
+				PublicKeyPem: publicPEM,
+			},
+		})
+	}))
+	defer evil.Close()
+
+	_, err := testClient(evil).FetchActor(context.Background(), evil.URL+"/k#main-key")
+	if err == nil {
+		t.Errorf("actor from another origin was fetched")
+	}
+
+	// a redirect to another origin does not count as the actor's origin either
+	redirect := httptest.NewTLSServer(http.RedirectHandler(victim.URL+"/users/alice", http.StatusFound))
+	defer redirect.Close()
+
+	_, err = testClient(redirect).FetchActor(context.Background(), redirect.URL+"/users/alice#main-key")
+	if err == nil {
+		t.Errorf("actor behind a cross-origin redirect was fetched")
+	}
+}
+
+func TestFetchActorForbidden(t *testing.T) {
+	_, publicPEM, _ := httpsig.GenerateKey()
+	server := standIn(t, publicPEM, nil)
+	defer server.Close()
+
+	// the real dialer refuses the stand-in, it listens on loopback
+	_, err := NewClient().FetchActor(context.Background(), server.URL+"/users/alice")
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("ERROR: %v", err)
+	}
+
+	_, err = testClient(server).FetchActor(context.Background(), "http://"+server.Listener.Addr().String()+"/users/alice")
+	if !errors.Is(err, ErrInsecureURL) {
+		t.Errorf("ERROR: %v", err)
+	}
+
+	// a redirect to plain http is refused too
+	redirect := httptest.NewTLSServer(http.RedirectHandler("http://"+server.Listener.Addr().String()+"/users/alice", http.StatusFound))
+	defer redirect.Close()
+
+	_, err = testClient(redirect).FetchActor(context.Background(), redirect.URL+"/users/alice")
+	if !errors.Is(err, ErrInsecureURL) {
+		t.Errorf("ERROR: %v", err)
+	}
+}
+
+func TestCheckAddress(t *testing.T) {
+	blocked := []string{"127.0.0.1:443", "10.1.2.3:443", "172.16.0.1:443", "192.168.1.1:443", "169.254.169.254:80", "100.64.0.1:443", "0.0.0.0:443", "[::1]:443", "[fe80::1]:443", "[fd00::1]:443", "[::ffff:127.0.0.1]:443"}
+	for _, el := range blocked {
+		if !errors.Is(checkAddress("tcp", el, nil), ErrForbiddenAddress) {
+			t.Errorf("%s was allowed", el)
+		}
+	}
+
+	allowed := []string{"93.184.216.34:443", "[2606:4700::1111]:443"}
+	for _, el := range allowed {
+		if err := checkAddress("tcp", el, nil); err != nil {
+			t.Errorf("ERROR: %v", err)
+		}
+	}
+}
+
+func TestFetchActorRateLimit(t *testing.T) {
+	_, publicPEM, _ := httpsig.GenerateKey()
+	server := standIn(t, publicPEM, nil)
+	defer server.Close()
+
+	client := testClient(server)
+	for i := 0; i < maxFetchesPerHost; i++ {
+		_, err := client.FetchActor(context.Background(), server.URL+"/users/alice")
+		if err != nil {
+			t.Fatalf("ERROR: %v", err)
+		}
+	}
+
+	_, err := client.FetchActor(context.Background(), server.URL+"/users/alice")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("ERROR: %v", err)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(time.Hour, 2, 3)
+	if !l.allow("a.test") || !l.allow("a.test") || l.allow("a.test") {
+		t.Errorf("per host limit was not kept")
+	}
+	if !l.allow("b.test") || l.allow("c.test") {
+		t.Errorf("total limit was not kept")
+	}
+
+	l.start = time.Now().Add(-2 * time.Hour)
+	if !l.allow("a.test") {
+		t.Errorf("limit was not reset after the window")
+	}
+}
+
+func TestSameOrigin(t *testing.T) {
+	if !SameOrigin("https://remote.test/users/alice", "https://REMOTE.test/users/alice#main-key") {
+		t.Errorf("same origin was rejected")
+	}
+	if SameOrigin("https://remote.test/users/alice", "https://remote.test:8443/users/alice") {
+		t.Errorf("another port was accepted")
+	}
+	if SameOrigin("https://remote.test/users/alice", "http://remote.test/users/alice") {
+		t.Errorf("another scheme was accepted")
+	}
+	if SameOrigin("https://remote.test/users/alice", "/users/alice") {
+		t.Errorf("relative IRI was accepted")
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	privatePEM, publicPEM, _ := httpsig.GenerateKey()
+	received := make(chan Activity, 1)
+	server := standIn(t, publicPEM, received)
+	defer server.Close()
+
+	key, _ := httpsig.ParsePrivateKey(privatePEM)
+	activity := []byte(`{"id":"https://chirpy.test/1#create","type":"Create","actor":"https://chirpy.test/users/1","object":{"id":"https://chirpy.test/chirps/1","type":"Note"}}`)
+
+	err := testClient(server).Deliver(context.Background(), server.URL+"/inbox", activity, "https://chirpy.test/users/1#main-key", key)
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	got := <-received
+	if got.Type != "Create" || got.ObjectID() != "https://chirpy.test/chirps/1" || got.ObjectType() != "Note" {
+		t.Errorf("invalid activity: %+v", got)
+	}
+
+	otherPEM, _, _ := httpsig.GenerateKey()
+	otherKey, _ := httpsig.ParsePrivateKey(otherPEM)
+	err = testClient(server).Deliver(context.Background(), server.URL+"/inbox", activity, "https://chirpy.test/users/1#main-key", otherKey)
+	if err == nil {
+		t.Errorf("badly signed delivery was accepted")
+	}
+}
+
+func TestActivityObject(t *testing.T) {
+	undo := Activity{Object: json.RawMessage(`{"id":"https://remote.test/follows/1","type":"Follow","object":"https://chirpy.test/users/1"}`)}
+	if undo.ObjectID() != "https://remote.test/follows/1" || undo.InnerObjectID() != "https://chirpy.test/users/1" {
+		t.Errorf("invalid object of embedded activity")
+	}
+
+	like := Activity{Object: json.RawMessage(`"https://chirpy.test/chirps/1"`)}
+	if like.ObjectID() != "https://chirpy.test/chirps/1" || like.ObjectType() != "" {
+		t.Errorf("invalid object of IRI")
+	}
+}
@@ -0,0 +1,45 @@
+package activitypub
+
+import (
+	"sync"
+	"time"
+)
+
+// limiter allows up to perHost calls for each host and total calls overall per window.
+type limiter struct {
+	mu      sync.Mutex
+	window  time.Duration
+	perHost int
+	total   int
+	start   time.Time
+	hosts   map[string]int
+	count   int
+}
+
+func newLimiter(window time.Duration, perHost, total int) *limiter {
+	return &limiter{
+		window:  window,
+		perHost: perHost,
+		total:   total,
+		hosts:   map[string]int{},
+	}
+}
+
+func (l *limiter) allow(host string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.start) >= l.window {
+		l.start = now
+		l.hosts = map[string]int{}
+		l.count = 0
+	}
+
+	if l.count >= l.total || l.hosts[host] >= l.perHost {
+		return false
+	}
+	l.count++
+	l.hosts[host]++
+	return true
+}
@@ -7,27 +7,95 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const deleteChirp = `-- name: DeleteChirp :exec
-DELETE FROM chirps
-WHERE id = $1
+const countQuotes = `-- name: CountQuotes :many
+SELECT quote_of, COUNT(*) AS quote_count FROM chirps
+WHERE quote_of = ANY($1::uuid[]) AND deleted_at IS NULL AND scheduled_at IS NULL
+GROUP BY quote_of
 `
 
-func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, deleteChirp, id)
-	return err
+type CountQuotesRow struct {
+	QuoteOf    uuid.NullUUID
+	QuoteCount int64
+}
+
+func (q *Queries) CountQuotes(ctx context.Context, chirpIds []uuid.UUID) ([]CountQuotesRow, error) {
+	rows, err := q.db.QueryContext(ctx, countQuotes, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountQuotesRow
+	for rows.Next() {
+		var i CountQuotesRow
+		if err := rows.Scan(
+			&i.QuoteOf,
+			&i.QuoteCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countReplies = `-- name: CountReplies :many
+SELECT in_reply_to, COUNT(*) AS reply_count FROM chirps
+WHERE in_reply_to = ANY($1::uuid[]) AND deleted_at IS NULL AND scheduled_at IS NULL
+GROUP BY in_reply_to
+`
+
+type CountRepliesRow struct {
+	InReplyTo  uuid.NullUUID
+	ReplyCount int64
+}
+
+func (q *Queries) CountReplies(ctx context.Context, chirpIds []uuid.UUID) ([]CountRepliesRow, error) {
+	rows, err := q.db.QueryContext(ctx, countReplies, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountRepliesRow
+	for rows.Next() {
+		var i CountRepliesRow
+		if err := rows.Scan(
+			&i.InReplyTo,
+			&i.ReplyCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+const getChirpByUserID = `-- name: GetChirpByUserID :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, thread_id, edited_at, quote_of, deleted_at, scheduled_at, visibility FROM chirps
+WHERE user_id = $1 AND deleted_at IS NULL AND scheduled_at IS NULL
 ORDER BY created_at
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
+func (q *Queries) GetChirpByUserID(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpByUserID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -41,6 +109,13 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.ThreadID,
+			&i.EditedAt,
+			&i.QuoteOf,
+			&i.DeletedAt,
+			&i.ScheduledAt,
+			&i.Visibility,
 		); err != nil {
 			return nil, err
 		}
@@ -55,14 +130,91 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 	return items, nil
 }
 
-const getChirpByUserID = `-- name: GetChirpByUserID :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE user_id = $1
-ORDER BY created_at
+const getChirpForUpdate = `-- name: GetChirpForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, thread_id, edited_at, quote_of, deleted_at, scheduled_at, visibility FROM chirps
+WHERE id = $1
+FOR UPDATE
 `
 
-func (q *Queries) GetChirpByUserID(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpByUserID, userID)
+func (q *Queries) GetChirpForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.ThreadID,
+		&i.EditedAt,
+		&i.QuoteOf,
+		&i.DeletedAt,
+		&i.ScheduledAt,
+		&i.Visibility,
+	)
+	return i, err
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, thread_id, edited_at, quote_of, deleted_at, scheduled_at, visibility FROM chirps
+WHERE id = ANY($1::uuid[])
+    AND deleted_at IS NULL
+    AND scheduled_at IS NULL
+    AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, $2)
+`
+
+type GetChirpsByIDsParams struct {
+	ChirpIds []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, arg GetChirpsByIDsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(arg.ChirpIds), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ThreadID,
+			&i.EditedAt,
+			&i.QuoteOf,
+			&i.DeletedAt,
+			&i.ScheduledAt,
+			&i.Visibility,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDueChirps = `-- name: GetDueChirps :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, thread_id, edited_at, quote_of, deleted_at, scheduled_at, visibility FROM chirps
+WHERE scheduled_at <= NOW() AND deleted_at IS NULL
+ORDER BY scheduled_at, id
+LIMIT $1
+FOR UPDATE SKIP LOCKED
+`
+
+func (q *Queries) GetDueChirps(ctx context.Context, limit int32) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getDueChirps, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -76,6 +228,13 @@ func (q *Queries) GetChirpByUserID(ctx context.Context, userID uuid.UUID) ([]Chi
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.ThreadID,
+			&i.EditedAt,
+			&i.QuoteOf,
+			&i.DeletedAt,
+			&i.ScheduledAt,
+			&i.Visibility,
 		); err != nil {
 			return nil, err
 		}
@@ -91,7 +250,7 @@ func (q *Queries) GetChirpByUserID(ctx context.Context, userID uuid.UUID) ([]Chi
 }
 
 const getOneChirp = `-- name: GetOneChirp :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, thread_id, edited_at, quote_of, deleted_at, scheduled_at, visibility FROM chirps
 WHERE id = $1
 `
 
@@ -104,29 +263,395 @@ func (q *Queries) GetOneChirp(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.ThreadID,
+		&i.EditedAt,
+		&i.QuoteOf,
+		&i.DeletedAt,
+		&i.ScheduledAt,
+		&i.Visibility,
 	)
 	return i, err
 }
 
+const getThread = `-- name: GetThread :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.thread_id, chirps.edited_at, chirps.quote_of, chirps.deleted_at, chirps.scheduled_at, chirps.visibility,
+    chirp_visible(chirps.id, chirps.user_id, chirps.visibility, $1)::boolean AS visible
+FROM chirps
+WHERE (chirps.id = $2 OR chirps.thread_id = $2)
+    AND chirps.scheduled_at IS NULL
+ORDER BY chirps.created_at, chirps.id
+`
+
+type GetThreadParams struct {
+	ViewerID uuid.NullUUID
+	ThreadID uuid.UUID
+}
+
+type GetThreadRow struct {
+	Chirp   Chirp
+	Visible bool
+}
+
+func (q *Queries) GetThread(ctx context.Context, arg GetThreadParams) ([]GetThreadRow, error) {
+	rows, err := q.db.QueryContext(ctx, getThread, arg.ViewerID, arg.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetThreadRow
+	for rows.Next() {
+		var i GetThreadRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.ThreadID,
+			&i.Chirp.EditedAt,
+			&i.Chirp.QuoteOf,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.ScheduledAt,
+			&i.Chirp.Visibility,
+			&i.Visible,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserFeedUpdatedAt = `-- name: GetUserFeedUpdatedAt :one
+SELECT COALESCE(MAX(updated_at), 'epoch')::timestamp AS updated_at FROM chirps
+WHERE user_id = $1 AND scheduled_at IS NULL AND visibility = 'public'
+`
+
+func (q *Queries) GetUserFeedUpdatedAt(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getUserFeedUpdatedAt, userID)
+	var updated_at time.Time
+	err := row.Scan(&updated_at)
+	return updated_at, err
+}
+
 const insertChirp = `-- name: InsertChirp :one
-INSERT INTO chirps(id, created_at, updated_at, body, user_id)
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, in_reply_to, thread_id, quote_of, scheduled_at, visibility)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, thread_id, edited_at, quote_of, deleted_at, scheduled_at, visibility
 `
 
 type InsertChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body        string
+	UserID      uuid.UUID
+	InReplyTo   uuid.NullUUID
+	ThreadID    uuid.NullUUID
+	QuoteOf     uuid.NullUUID
+	ScheduledAt sql.NullTime
+	Visibility  string
 }
 
 func (q *Queries) InsertChirp(ctx context.Context, arg InsertChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, insertChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, insertChirp,
+		arg.Body,
+		arg.UserID,
+		arg.InReplyTo,
+		arg.ThreadID,
+		arg.QuoteOf,
+		arg.ScheduledAt,
+		arg.Visibility,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.ThreadID,
+		&i.EditedAt,
+		&i.QuoteOf,
+		&i.DeletedAt,
+		&i.ScheduledAt,
+		&i.Visibility,
+	)
+	return i, err
+}
+
+const isChirpVisible = `-- name: IsChirpVisible :one
+SELECT chirp_visible(chirps.id, chirps.user_id, chirps.visibility, $1)::boolean AS visible FROM chirps
+WHERE chirps.id = $2
+`
+
+type IsChirpVisibleParams struct {
+	ViewerID uuid.NullUUID
+	ID       uuid.UUID
+}
+
+func (q *Queries) IsChirpVisible(ctx context.Context, arg IsChirpVisibleParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isChirpVisible, arg.ViewerID, arg.ID)
+	var visible bool
+	err := row.Scan(&visible)
+	return visible, err
+}
+
+const listChirps = `-- name: ListChirps :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, thread_id, edited_at, quote_of, deleted_at, scheduled_at, visibility FROM chirps
+WHERE deleted_at IS NULL
+    AND scheduled_at IS NULL
+    AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, $1)
+    AND ($2::uuid IS NULL OR user_id = $2)
+    AND (
+        $3::timestamp IS NULL
+        OR (created_at, id) > ($3::timestamp, $4::uuid)
+    )
+ORDER BY created_at, id
+LIMIT $5
+`
+
+type ListChirpsParams struct {
+	ViewerID        uuid.NullUUID
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	MaxRows         int32
+}
+
+func (q *Queries) ListChirps(ctx context.Context, arg ListChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirps,
+		arg.ViewerID,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ThreadID,
+			&i.EditedAt,
+			&i.QuoteOf,
+			&i.DeletedAt,
+			&i.ScheduledAt,
+			&i.Visibility,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsDesc = `-- name: ListChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, thread_id, edited_at, quote_of, deleted_at, scheduled_at, visibility FROM chirps
+WHERE deleted_at IS NULL
+    AND scheduled_at IS NULL
+    AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, $1)
+    AND ($2::uuid IS NULL OR user_id = $2)
+    AND (
+        $3::timestamp IS NULL
+        OR (created_at, id) < ($3::timestamp, $4::uuid)
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT $5
+`
+
+type ListChirpsDescParams struct {
+	ViewerID        uuid.NullUUID
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	MaxRows         int32
+}
+
+func (q *Queries) ListChirpsDesc(ctx context.Context, arg ListChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsDesc,
+		arg.ViewerID,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ThreadID,
+			&i.EditedAt,
+			&i.QuoteOf,
+			&i.DeletedAt,
+			&i.ScheduledAt,
+			&i.Visibility,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScheduledChirps = `-- name: ListScheduledChirps :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, thread_id, edited_at, quote_of, deleted_at, scheduled_at, visibility FROM chirps
+WHERE user_id = $1 AND scheduled_at IS NOT NULL AND deleted_at IS NULL
+ORDER BY scheduled_at, id
+`
+
+func (q *Queries) ListScheduledChirps(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listScheduledChirps, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ThreadID,
+			&i.EditedAt,
+			&i.QuoteOf,
+			&i.DeletedAt,
+			&i.ScheduledAt,
+			&i.Visibility,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const publishChirp = `-- name: PublishChirp :one
+UPDATE chirps
+SET created_at = NOW(),
+    updated_at = NOW(),
+    edited_at = NULL,
+    scheduled_at = NULL
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, thread_id, edited_at, quote_of, deleted_at, scheduled_at, visibility
+`
+
+func (q *Queries) PublishChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, publishChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.ThreadID,
+		&i.EditedAt,
+		&i.QuoteOf,
+		&i.DeletedAt,
+		&i.ScheduledAt,
+		&i.Visibility,
+	)
+	return i, err
+}
+
+const purgeDeletedChirps = `-- name: PurgeDeletedChirps :exec
+DELETE FROM chirps
+WHERE deleted_at < $1
+`
+
+func (q *Queries) PurgeDeletedChirps(ctx context.Context, deletedAt sql.NullTime) error {
+	_, err := q.db.ExecContext(ctx, purgeDeletedChirps, deletedAt)
+	return err
+}
+
+const softDeleteChirp = `-- name: SoftDeleteChirp :exec
+UPDATE chirps
+SET deleted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) SoftDeleteChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, softDeleteChirp, id)
+	return err
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $1,
+    updated_at = NOW(),
+    edited_at = NOW()
+WHERE id = $2
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, thread_id, edited_at, quote_of, deleted_at, scheduled_at, visibility
+`
+
+type UpdateChirpBodyParams struct {
+	Body string
+	ID   uuid.UUID
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.Body, arg.ID)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -134,6 +659,13 @@ func (q *Queries) InsertChirp(ctx context.Context, arg InsertChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.ThreadID,
+		&i.EditedAt,
+		&i.QuoteOf,
+		&i.DeletedAt,
+		&i.ScheduledAt,
+		&i.Visibility,
 	)
 	return i, err
 }
@@ -10,7 +10,7 @@ import (
 )
 
 const getUserHashedPassword = `-- name: GetUserHashedPassword :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url FROM users
 WHERE email = $1
 `
 
@@ -24,6 +24,10 @@ func (q *Queries) GetUserHashedPassword(ctx context.Context, email string) (User
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
 	)
 	return i, err
 }
@@ -0,0 +1,32 @@
+package entities
+
+import (
+	"errors"
+	"unicode"
+)
+
+const MinHandleLength = 3
+
+// ValidateHandle checks a handle against the same alphabet Mentions accepts,
+// so every valid handle can be @mentioned.
+func ValidateHandle(handle string) error {
+	if len(handle) < MinHandleLength || len(handle) > MaxHandleLength {
+		return errors.New("Handle must be 3 to 30 characters long")
+	}
+
+	hasLetter := false
+	for _, ch := range handle {
+		if !isHandleRune(ch) {
+			return errors.New("Handle may only contain letters, digits and underscores")
+		}
+		if unicode.IsLetter(ch) {
+			hasLetter = true
+		}
+	}
+
+	if !hasLetter {
+		return errors.New("Handle must contain a letter")
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package entities
+
+import "testing"
+
+func TestValidateHandle(t *testing.T) {
+	for _, handle := range []string{"alice", "Bob_99", "___x"} {
+		err := ValidateHandle(handle)
+		if err != nil {
+			t.Errorf("ERROR: %s: %v", handle, err)
+		}
+	}
+}
+
+func TestValidateHandle2(t *testing.T) {
+	for _, handle := range []string{"", "ab", "12345", "zoë", "white space", "a_really_long_handle_over_thirty"} {
+		err := ValidateHandle(handle)
+		if err == nil {
+			t.Errorf("invalid handle was passed: %s", handle)
+		}
+	}
+}
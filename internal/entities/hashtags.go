@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+)
+
+const maxHashtagLength = 50
+
+// Hashtags returns the lowercased, de-duplicated #tags of a chirp body in order of appearance.
+// A tag has to follow a non-word character and contain at least one letter, so "a#b" and "#123" don't count.
+func Hashtags(body string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+	runes := []rune(body)
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' || (i > 0 && isWordRune(runes[i-1])) {
+			continue
+		}
+
+		end := i + 1
+		hasLetter := false
+		for end < len(runes) && isWordRune(runes[end]) {
+			if unicode.IsLetter(runes[end]) {
+				hasLetter = true
+			}
+			end++
+		}
+
+		tag := strings.ToLower(string(runes[i+1 : end]))
+		i = end - 1
+
+		if !hasLetter || len(tag) > maxHashtagLength || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+func isWordRune(ch rune) bool {
+	return unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '_'
+}
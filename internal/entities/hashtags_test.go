@@ -0,0 +1,23 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHashtags(t *testing.T) {
+	tags := Hashtags("Loving #Golang and #golang, #go_1 too! #123 a#b (#Chirpy)")
+
+	expected := []string{"golang", "go_1", "chirpy"}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("invalid result: %v", tags)
+	}
+}
+
+func TestHashtags2(t *testing.T) {
+	tags := Hashtags("no tags here # just #")
+
+	if len(tags) != 0 {
+		t.Errorf("invalid result: %v", tags)
+	}
+}
@@ -0,0 +1,50 @@
+package entities
+
+import "strings"
+
+const MaxHandleLength = 30
+
+type Mention struct {
+	Handle string
+	Start  int
+	End    int
+}
+
+// Mentions finds @handles in a chirp body. Start and End are offsets in characters (runes),
+// End being exclusive and covering the leading @. An @ right after a word character is
+// skipped so email addresses aren't picked up.
+func Mentions(body string) []Mention {
+	mentions := []Mention{}
+	runes := []rune(body)
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && isWordRune(runes[i-1])) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isHandleRune(runes[end]) {
+			end++
+		}
+
+		// "@zoë" is not a mention of "zo"
+		followedByWord := end < len(runes) && isWordRune(runes[end])
+
+		handle := string(runes[i+1 : end])
+		if handle != "" && len(handle) <= MaxHandleLength && !followedByWord {
+			mentions = append(mentions, Mention{
+				Handle: strings.ToLower(handle),
+				Start:  i,
+				End:    end,
+			})
+		}
+
+		i = end - 1
+	}
+
+	return mentions
+}
+
+func isHandleRune(ch rune) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') || ch == '_'
+}
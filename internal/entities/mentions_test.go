@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMentions(t *testing.T) {
+	mentions := Mentions("hi @Alice, ping @bob_99 — mail me at me@example.com ✉ @")
+
+	expected := []Mention{
+		{Handle: "alice", Start: 3, End: 9},
+		{Handle: "bob_99", Start: 16, End: 23},
+	}
+	if !reflect.DeepEqual(mentions, expected) {
+		t.Errorf("invalid result: %v", mentions)
+	}
+}
+
+func TestMentions2(t *testing.T) {
+	mentions := Mentions("ünïcödé @zoë @max")
+
+	expected := []Mention{{Handle: "max", Start: 13, End: 17}}
+	if !reflect.DeepEqual(mentions, expected) {
+		t.Errorf("invalid result: %v", mentions)
+	}
+}
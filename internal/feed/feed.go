@@ -0,0 +1,169 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const titleLength = 50
+
+// Feed is what Atom and RSS render. IDs and links have to be absolute.
+type Feed struct {
+	ID          string
+	Title       string
+	Description string
+	Link        string
+	SelfLink    string
+	Updated     time.Time
+	Entries     []Entry
+}
+
+type Entry struct {
+	ID        string
+	Title     string
+	Link      string
+	Author    string
+	Content   string
+	Published time.Time
+	Updated   time.Time
+}
+
+// Title shortens a body to its first line, cut at titleLength runes.
+func Title(body string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(body), "\n")
+	if utf8.RuneCountInString(line) <= titleLength {
+		return line
+	}
+	return string([]rune(line)[:titleLength-1]) + "…"
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Link      atomLink   `xml:"link"`
+	Author    atomAuthor `xml:"author"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Content   atomText   `xml:"content"`
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+func Atom(f Feed) ([]byte, error) {
+	out := atomFeed{
+		ID:       f.ID,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  f.Updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: f.SelfLink},
+			{Rel: "alternate", Href: f.Link},
+		},
+	}
+
+	for _, el := range f.Entries {
+		out.Entries = append(out.Entries, atomEntry{
+			ID:        el.ID,
+			Title:     el.Title,
+			Link:      atomLink{Rel: "alternate", Href: el.Link},
+			Author:    atomAuthor{Name: el.Author},
+			Published: el.Published.UTC().Format(time.RFC3339),
+			Updated:   el.Updated.UTC().Format(time.RFC3339),
+			Content:   atomText{Type: "text", Body: el.Content},
+		})
+	}
+
+	return marshal(out)
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	Author      string  `xml:"dc:creator,omitempty"`
+	PubDate     string  `xml:"pubDate"`
+	Description string  `xml:"description"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	SelfLink      atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+func RSS(f Feed) ([]byte, error) {
+	out := rss{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Description,
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+			SelfLink:      atomLink{Rel: "self", Type: "application/rss+xml", Href: f.SelfLink},
+		},
+	}
+
+	for _, el := range f.Entries {
+		out.Channel.Items = append(out.Channel.Items, rssItem{
+			Title:       el.Title,
+			Link:        el.Link,
+			GUID:        rssGUID{IsPermaLink: false, Value: el.ID},
+			Author:      el.Author,
+			PubDate:     el.Published.UTC().Format(time.RFC1123Z),
+			Description: el.Content,
+		})
+	}
+
+	return marshal(out)
+}
+
+func marshal(v any) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,70 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testFeed = Feed{
+	ID:       "https://chirpy.test/users/1/feed.atom",
+	Title:    "@alice on Chirpy",
+	Link:     "https://chirpy.test/api/users/alice",
+	SelfLink: "https://chirpy.test/users/1/feed.atom",
+	Updated:  time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	Entries: []Entry{{
+		ID:        "urn:uuid:2",
+		Title:     "hello <world>",
+		Link:      "https://chirpy.test/api/chirps/2",
+		Author:    "@alice",
+		Content:   "hello <world> & more",
+		Published: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Updated:   time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}},
+}
+
+func TestAtom(t *testing.T) {
+	data, err := Atom(testFeed)
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	parsed := atomFeed{}
+	err = xml.Unmarshal(data, &parsed)
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	if parsed.Updated != "2025-01-02T03:04:05Z" || len(parsed.Entries) != 1 {
+		t.Errorf("invalid feed: %s", data)
+	}
+	if parsed.Entries[0].Content.Body != "hello <world> & more" {
+		t.Errorf("invalid content: %q", parsed.Entries[0].Content.Body)
+	}
+}
+
+func TestRSS(t *testing.T) {
+	data, err := RSS(testFeed)
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	if !strings.Contains(string(data), "<pubDate>Thu, 02 Jan 2025 03:04:05 +0000</pubDate>") {
+		t.Errorf("invalid pubDate: %s", data)
+	}
+	if !strings.Contains(string(data), `<guid isPermaLink="false">urn:uuid:2</guid>`) {
+		t.Errorf("invalid guid: %s", data)
+	}
+}
+
+func TestTitle(t *testing.T) {
+	if title := Title("first line\nsecond line"); title != "first line" {
+		t.Errorf("invalid title: %q", title)
+	}
+
+	title := Title(strings.Repeat("a", 60))
+	if len([]rune(title)) != titleLength || !strings.HasSuffix(title, "…") {
+		t.Errorf("invalid title: %q", title)
+	}
+}
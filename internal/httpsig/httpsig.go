@@ -0,0 +1,245 @@
+package httpsig
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MaxClockSkew is how far the Date of a signed request may be from now.
+const MaxClockSkew = time.Hour
+
+var (
+	ErrNoSignature     = errors.New("Request is not signed")
+	ErrInvalidHeader   = errors.New("Invalid Signature header")
+	ErrBadAlgorithm    = errors.New("Unsupported signature algorithm")
+	ErrMissingHeader   = errors.New("Signed header is missing")
+	ErrDigestMismatch  = errors.New("Digest doesn't match the body")
+	ErrStaleDate       = errors.New("Date is outside the allowed window")
+	ErrInvalidSig      = errors.New("Signature doesn't verify")
+	ErrInvalidKey      = errors.New("Invalid key")
+	ErrUnsignedHeaders = errors.New("Signature doesn't cover the required headers")
+)
+
+// Signature is a parsed Signature header, as in draft-cavage-http-signatures.
+type Signature struct {
+	KeyID     string
+	Algorithm string
+	Headers   []string
+	Value     []byte
+}
+
+// Sign signs r with rsa-sha256 over (request-target), host and date, plus digest when there is a body.
+// It sets the Date, Digest and Signature headers.
+func Sign(r *http.Request, keyID string, key *rsa.PrivateKey, body []byte) error {
+	if r.Header.Get("Date") == "" {
+		r.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	}
+
+	headers := []string{"(request-target)", "host", "date"}
+	if body != nil {
+		r.Header.Set("Digest", Digest(body))
+		headers = append(headers, "digest")
+	}
+
+	hashed := sha256.Sum256([]byte(signingString(r, headers)))
+	value, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return err
+	}
+
+	r.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyID, strings.Join(headers, " "), base64.StdEncoding.EncodeToString(value)))
+	return nil
+}
+
+// Digest returns the Digest header value of body.
+func Digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// ParseSignature reads the Signature header of r, so the caller can look up the key by KeyID.
+func ParseSignature(r *http.Request) (Signature, error) {
+	header := r.Header.Get("Signature")
+	if header == "" {
+		return Signature{}, ErrNoSignature
+	}
+
+	sig := Signature{
+		Algorithm: "hs2019",
+		Headers:   []string{"date"},
+	}
+	for _, el := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(el), "=")
+		if !ok || len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+			return Signature{}, ErrInvalidHeader
+		}
+		value = value[1 : len(value)-1]
+
+		switch name {
+		case "keyId":
+			sig.KeyID = value
+		case "algorithm":
+			sig.Algorithm = value
+		case "headers":
+			sig.Headers = strings.Fields(strings.ToLower(value))
+		case "signature":
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return Signature{}, ErrInvalidHeader
+			}
+			sig.Value = decoded
+		}
+	}
+
+	if sig.KeyID == "" || sig.Value == nil {
+		return Signature{}, ErrInvalidHeader
+	}
+
+	return sig, nil
+}
+
+// Verify checks sig against r with key. The signature has to cover (request-target), host and date,
+// and digest when there is a body, which then has to match.
+func Verify(r *http.Request, body []byte, sig Signature, key *rsa.PublicKey) error {
+	// hs2019 leaves the algorithm to the key, which is always RSA here
+	if sig.Algorithm != "rsa-sha256" && sig.Algorithm != "hs2019" {
+		return ErrBadAlgorithm
+	}
+
+	required := []string{"(request-target)", "host", "date"}
+	if len(body) > 0 {
+		required = append(required, "digest")
+	}
+	for _, el := range required {
+		if !contains(sig.Headers, el) {
+			return ErrUnsignedHeaders
+		}
+	}
+
+	for _, el := range sig.Headers {
+		if el != "(request-target)" && el != "host" && r.Header.Get(el) == "" {
+			return ErrMissingHeader
+		}
+	}
+
+	if len(body) > 0 && r.Header.Get("Digest") != Digest(body) {
+		return ErrDigestMismatch
+	}
+
+	date, err := http.ParseTime(r.Header.Get("Date"))
+	if err != nil || time.Since(date).Abs() > MaxClockSkew {
+		return ErrStaleDate
+	}
+
+	hashed := sha256.Sum256([]byte(signingString(r, sig.Headers)))
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], sig.Value)
+	if err != nil {
+		return ErrInvalidSig
+	}
+
+	return nil
+}
+
+func signingString(r *http.Request, headers []string) string {
+	lines := []string{}
+	for _, el := range headers {
+		switch el {
+		case "(request-target)":
+			lines = append(lines, "(request-target): "+strings.ToLower(r.Method)+" "+r.URL.RequestURI())
+		case "host":
+			host := r.Host
+			if host == "" {
+				host = r.URL.Host
+			}
+			lines = append(lines, "host: "+host)
+		default:
+			lines = append(lines, el+": "+strings.Join(r.Header.Values(el), ", "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func contains(list []string, value string) bool {
+	for _, el := range list {
+		if el == value {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateKey returns a new RSA key pair as PKCS#8 and PKIX PEM.
+func GenerateKey() (string, string, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", "", err
+	}
+
+	private, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: private})
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public})
+	return string(privatePEM), string(publicPEM), nil
+}
+
+func ParsePrivateKey(privatePEM string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(privatePEM))
+	if block == nil {
+		return nil, ErrInvalidKey
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	return key, nil
+}
+
+// ParsePublicKey accepts PKIX ("PUBLIC KEY") and PKCS#1 ("RSA PUBLIC KEY") PEM, servers publish both.
+func ParsePublicKey(publicPEM string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicPEM))
+	if block == nil {
+		return nil, ErrInvalidKey
+	}
+
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	return key, nil
+}
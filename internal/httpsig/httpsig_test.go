@@ -0,0 +1,102 @@
+package httpsig
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func signedRequest(t *testing.T, privatePEM string, body []byte) *http.Request {
+	key, err := ParsePrivateKey(privatePEM)
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "https://chirpy.test/users/1/inbox", bytes.NewReader(body))
+	err = Sign(req, "https://remote.test/users/alice#main-key", key, body)
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+	return req
+}
+
+func TestSignVerify(t *testing.T) {
+	privatePEM, publicPEM, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+	publicKey, err := ParsePublicKey(publicPEM)
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	body := []byte(`{"type":"Follow"}`)
+	req := signedRequest(t, privatePEM, body)
+
+	sig, err := ParseSignature(req)
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+	if sig.KeyID != "https://remote.test/users/alice#main-key" || len(sig.Headers) != 4 {
+		t.Errorf("invalid signature: %+v", sig)
+	}
+
+	err = Verify(req, body, sig, publicKey)
+	if err != nil {
+		t.Errorf("ERROR: %v", err)
+	}
+
+	err = Verify(req, []byte(`{"type":"Undo"}`), sig, publicKey)
+	if !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("expected ErrDigestMismatch, got %v", err)
+	}
+}
+
+func TestVerify2(t *testing.T) {
+	privatePEM, _, _ := GenerateKey()
+	_, otherPEM, _ := GenerateKey()
+	otherKey, _ := ParsePublicKey(otherPEM)
+
+	body := []byte(`{"type":"Like"}`)
+	req := signedRequest(t, privatePEM, body)
+	sig, _ := ParseSignature(req)
+
+	err := Verify(req, body, sig, otherKey)
+	if !errors.Is(err, ErrInvalidSig) {
+		t.Errorf("expected ErrInvalidSig, got %v", err)
+	}
+}
+
+func TestVerify3(t *testing.T) {
+	privatePEM, publicPEM, _ := GenerateKey()
+	publicKey, _ := ParsePublicKey(publicPEM)
+	key, _ := ParsePrivateKey(privatePEM)
+
+	body := []byte(`{}`)
+	req := httptest.NewRequest(http.MethodPost, "https://chirpy.test/inbox", bytes.NewReader(body))
+	req.Header.Set("Date", time.Now().Add(-2*MaxClockSkew).UTC().Format(http.TimeFormat))
+	Sign(req, "key", key, body)
+	sig, _ := ParseSignature(req)
+
+	err := Verify(req, body, sig, publicKey)
+	if !errors.Is(err, ErrStaleDate) {
+		t.Errorf("expected ErrStaleDate, got %v", err)
+	}
+}
+
+func TestParseSignature(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	_, err := ParseSignature(req)
+	if !errors.Is(err, ErrNoSignature) {
+		t.Errorf("expected ErrNoSignature, got %v", err)
+	}
+
+	req.Header.Set("Signature", `keyId="k",signature`)
+	_, err = ParseSignature(req)
+	if !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("expected ErrInvalidHeader, got %v", err)
+	}
+}
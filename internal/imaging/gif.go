@@ -0,0 +1,85 @@
+package imaging
+
+// stripGIF copies a GIF block by block, keeping the frames, their graphic control extensions and
+// the loop count, and dropping comments, plain text and every other application extension (XMP,
+// ICC profiles...). Animated GIFs can't be re-encoded without losing frames, so this is how their
+// metadata goes. Anything after the trailer is dropped as well.
+func stripGIF(data []byte) ([]byte, error) {
+	if len(data) < 13 || (string(data[:6]) != "GIF87a" && string(data[:6]) != "GIF89a") {
+		return nil, ErrUnsupported
+	}
+
+	// header, logical screen descriptor and global color table
+	offset := 13 + colorTableSize(data[10])
+	if offset > len(data) {
+		return nil, ErrUnsupported
+	}
+	out := append(make([]byte, 0, len(data)), data[:offset]...)
+
+	for offset < len(data) {
+		start := offset
+		switch data[offset] {
+		case 0x2C:
+			// image descriptor, local color table and the LZW minimum code size
+			if offset+10 > len(data) {
+				return nil, ErrUnsupported
+			}
+			offset += 10 + colorTableSize(data[offset+9]) + 1
+
+			end, ok := skipSubBlocks(data, offset)
+			if !ok {
+				return nil, ErrUnsupported
+			}
+			out = append(out, data[start:end]...)
+			offset = end
+		case 0x21:
+			if offset+2 > len(data) {
+				return nil, ErrUnsupported
+			}
+			label := data[offset+1]
+
+			end, ok := skipSubBlocks(data, offset+2)
+			if !ok {
+				return nil, ErrUnsupported
+			}
+			if label == 0xF9 || (label == 0xFF && isLoopExtension(data[offset+2:end])) {
+				out = append(out, data[start:end]...)
+			}
+			offset = end
+		case 0x3B:
+			return append(out, 0x3B), nil
+		default:
+			return nil, ErrUnsupported
+		}
+	}
+
+	return nil, ErrUnsupported
+}
+
+func colorTableSize(packed byte) int {
+	if packed&0x80 == 0 {
+		return 0
+	}
+	return 3 << (packed&0x07 + 1)
+}
+
+// skipSubBlocks returns the offset right after the data sub-blocks starting at offset.
+func skipSubBlocks(data []byte, offset int) (int, bool) {
+	for offset < len(data) {
+		size := int(data[offset])
+		offset += 1 + size
+		if size == 0 {
+			return offset, true
+		}
+	}
+	return 0, false
+}
+
+// isLoopExtension tells whether the sub-blocks of an application extension hold the loop count.
+func isLoopExtension(blocks []byte) bool {
+	if len(blocks) < 12 || blocks[0] != 11 {
+		return false
+	}
+	id := string(blocks[1:12])
+	return id == "NETSCAPE2.0" || id == "ANIMEXTS1.0"
+}
@@ -0,0 +1,155 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+const (
+	MaxSourceSide   = 10000
+	MaxSourcePixels = 16_000_000
+	MaxSide         = 2048
+	MaxOutputBytes  = 5 << 20
+	jpegQuality     = 85
+	// a decode of MaxSourcePixels holds well over 100MB until the renditions are encoded
+	maxConcurrentDecodes = 2
+)
+
+var (
+	ErrUnsupported = errors.New("Unsupported image")
+	ErrTooLarge    = errors.New("Image is too large")
+)
+
+// decodeSlots bounds how many images are decoded at once, so concurrent uploads can't exhaust memory.
+var decodeSlots = make(chan struct{}, maxConcurrentDecodes)
+
+// renditionSizes are the long-side limits of the extra sizes made for every upload.
+var renditionSizes = []struct {
+	Name    string
+	MaxSide int
+}{
+	{Name: "medium", MaxSide: 1024},
+	{Name: "thumbnail", MaxSide: 320},
+}
+
+type Rendition struct {
+	Name        string
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+type Result struct {
+	Original   Rendition
+	Renditions []Rendition
+	BlurHash   string
+}
+
+// Process normalizes an uploaded image. The original is decoded, turned upright and re-encoded,
+// which drops EXIF and any other metadata, and is scaled down to MaxSide.
+// Animated GIFs are kept frame for frame so they keep moving, only their metadata blocks are stripped.
+func Process(data []byte) (Result, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, ErrUnsupported
+	}
+
+	if config.Width > MaxSourceSide || config.Height > MaxSourceSide || config.Width*config.Height > MaxSourcePixels {
+		return Result{}, ErrTooLarge
+	}
+
+	decodeSlots <- struct{}{}
+	defer func() { <-decodeSlots }()
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, ErrUnsupported
+	}
+
+	img := toRGBA(decoded)
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	result := Result{}
+	switch format {
+	case "gif":
+		if config.Width > MaxSide || config.Height > MaxSide {
+			return Result{}, ErrTooLarge
+		}
+		stripped, err := stripGIF(data)
+		if err != nil {
+			return Result{}, err
+		}
+		result.Original = Rendition{
+			Name:        "original",
+			Data:        stripped,
+			ContentType: "image/gif",
+			Width:       config.Width,
+			Height:      config.Height,
+		}
+	case "jpeg", "png":
+		result.Original, err = encode("original", fit(img, MaxSide), format)
+		if err != nil {
+			return Result{}, err
+		}
+	default:
+		return Result{}, ErrUnsupported
+	}
+
+	if len(result.Original.Data) > MaxOutputBytes {
+		return Result{}, ErrTooLarge
+	}
+
+	// renditions of GIFs are stills of the first frame, PNG keeps their transparency
+	renditionFormat := format
+	if format == "gif" {
+		renditionFormat = "png"
+	}
+
+	for _, size := range renditionSizes {
+		rendition, err := encode(size.Name, fit(img, size.MaxSide), renditionFormat)
+		if err != nil {
+			return Result{}, err
+		}
+		result.Renditions = append(result.Renditions, rendition)
+	}
+
+	result.BlurHash = blurHash(fit(img, 32), 4, 3)
+
+	return result, nil
+}
+
+func encode(name string, img *image.RGBA, format string) (Rendition, error) {
+	buf := bytes.Buffer{}
+	rendition := Rendition{
+		Name:   name,
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+	}
+
+	switch format {
+	case "jpeg":
+		err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+		if err != nil {
+			return Rendition{}, err
+		}
+		rendition.ContentType = "image/jpeg"
+	case "png":
+		err := png.Encode(&buf, img)
+		if err != nil {
+			return Rendition{}, err
+		}
+		rendition.ContentType = "image/png"
+	default:
+		return Rendition{}, ErrUnsupported
+	}
+
+	rendition.Data = buf.Bytes()
+	return rendition, nil
+}
@@ -0,0 +1,167 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testJPEG(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	buf := bytes.Buffer{}
+	err := jpeg.Encode(&buf, img, nil)
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+// withOrientation puts a big-endian EXIF segment holding only the orientation tag right after SOI.
+func withOrientation(data []byte, orientation byte) []byte {
+	exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00")
+	exif = append(exif, orientation, 0, 0, 0, 0, 0, 0)
+	length := len(exif) + 2
+
+	segment := append([]byte{0xFF, 0xE1, byte(length >> 8), byte(length)}, exif...)
+	return append(append([]byte{0xFF, 0xD8}, segment...), data[2:]...)
+}
+
+func TestProcess(t *testing.T) {
+	result, err := Process(testJPEG(t, 3000, 1500))
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	if result.Original.Width != 2048 || result.Original.Height != 1024 {
+		t.Errorf("original was not scaled down: %dx%d", result.Original.Width, result.Original.Height)
+	}
+
+	if len(result.Renditions) != 2 || result.Renditions[1].Name != "thumbnail" || result.Renditions[1].Width != 320 {
+		t.Errorf("invalid renditions")
+	}
+
+	if len(result.BlurHash) != 28 || result.BlurHash[0] != 'L' {
+		t.Errorf("invalid blurhash: %s", result.BlurHash)
+	}
+}
+
+func TestProcess2(t *testing.T) {
+	data := withOrientation(testJPEG(t, 40, 20), 6)
+
+	if jpegOrientation(data) != 6 {
+		t.Errorf("orientation was not read")
+	}
+
+	result, err := Process(data)
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	if result.Original.Width != 20 || result.Original.Height != 40 {
+		t.Errorf("image was not rotated: %dx%d", result.Original.Width, result.Original.Height)
+	}
+
+	if bytes.Contains(result.Original.Data, []byte("Exif")) {
+		t.Errorf("EXIF was not stripped")
+	}
+}
+
+func TestProcess3(t *testing.T) {
+	_, err := Process([]byte("definitely not an image"))
+	if err != ErrUnsupported {
+		t.Errorf("invalid image was passed")
+	}
+}
+
+// testGIF is a two frame animation with a comment and an XMP block in front of the trailer.
+func testGIF(t *testing.T) []byte {
+	anim := gif.GIF{LoopCount: 3}
+	for i := 0; i < 2; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, 16, 16), palette.Plan9)
+		frame.SetColorIndex(i, i, 200)
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, 10)
+	}
+
+	buf := bytes.Buffer{}
+	err := gif.EncodeAll(&buf, &anim)
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	data := buf.Bytes()
+	comment := append([]byte{0x21, 0xFE, 11}, "secret note"...)
+	xmp := append([]byte{0x21, 0xFF, 11}, "XMP DataXMP"...)
+	xmp = append(xmp, 6)
+	xmp = append(xmp, "secret"...)
+
+	extensions := append(append(comment, 0), append(xmp, 0)...)
+	return append(append(data[:len(data)-1:len(data)-1], extensions...), 0x3B)
+}
+
+func TestProcess4(t *testing.T) {
+	result, err := Process(testGIF(t))
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	if bytes.Contains(result.Original.Data, []byte("secret")) {
+		t.Errorf("GIF metadata was not stripped")
+	}
+
+	anim, err := gif.DecodeAll(bytes.NewReader(result.Original.Data))
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	if len(anim.Image) != 2 || anim.LoopCount != 3 || anim.Delay[1] != 10 {
+		t.Errorf("animation was not kept: %d frames, loop count %d", len(anim.Image), anim.LoopCount)
+	}
+}
+
+func TestProcess5(t *testing.T) {
+	buf := bytes.Buffer{}
+	err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1)))
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	// claim 5000x5000 in the IHDR chunk, DecodeConfig never gets to the pixels
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data[16:], 5000)
+	binary.BigEndian.PutUint32(data[20:], 5000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	_, err = Process(data)
+	if err != ErrTooLarge {
+		t.Errorf("image over MaxSourcePixels was passed: %v", err)
+	}
+}
+
+func TestStripGIF(t *testing.T) {
+	data := testGIF(t)
+
+	_, err := stripGIF(data[:len(data)-20])
+	if err != ErrUnsupported {
+		t.Errorf("truncated GIF was passed")
+	}
+
+	_, err = stripGIF([]byte("GIF89a"))
+	if err != ErrUnsupported {
+		t.Errorf("GIF without a screen descriptor was passed")
+	}
+}
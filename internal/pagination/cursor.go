@@ -0,0 +1,81 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func EncodeCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(cursor string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return Cursor{}, errors.New("Invalid cursor")
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 2 {
+		return Cursor{}, errors.New("Invalid cursor")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return Cursor{}, errors.New("Invalid cursor")
+	}
+
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return Cursor{}, errors.New("Invalid cursor")
+	}
+
+	return Cursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+// RankCursor continues a listing ordered by rank before time, like search by relevance.
+type RankCursor struct {
+	Rank float32
+	Cursor
+}
+
+// EncodeRankCursor keeps every bit of rank, so the next page starts exactly after the last row.
+func EncodeRankCursor(rank float32, createdAt time.Time, id uuid.UUID) string {
+	raw := strconv.FormatFloat(float64(rank), 'g', -1, 32) + "|" + createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeRankCursor(cursor string) (RankCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return RankCursor{}, errors.New("Invalid cursor")
+	}
+
+	rawRank, rest, found := strings.Cut(string(raw), "|")
+	if !found {
+		return RankCursor{}, errors.New("Invalid cursor")
+	}
+
+	rank, err := strconv.ParseFloat(rawRank, 32)
+	if err != nil {
+		return RankCursor{}, errors.New("Invalid cursor")
+	}
+
+	inner, err := DecodeCursor(base64.RawURLEncoding.EncodeToString([]byte(rest)))
+	if err != nil {
+		return RankCursor{}, err
+	}
+
+	return RankCursor{Rank: float32(rank), Cursor: inner}, nil
+}
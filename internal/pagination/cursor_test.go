@@ -0,0 +1,74 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursor(t *testing.T) {
+	createdAt := time.Date(2025, 4, 12, 10, 30, 0, 123456000, time.UTC)
+	id := uuid.New()
+
+	cursor, err := DecodeCursor(EncodeCursor(createdAt, id))
+	if err != nil {
+		t.Errorf("ERROR: %v", err)
+	}
+
+	if !cursor.CreatedAt.Equal(createdAt) || cursor.ID != id {
+		t.Errorf("invalid cursor was returned")
+	}
+}
+
+func TestCursor2(t *testing.T) {
+	_, err := DecodeCursor("not a cursor")
+	if err == nil {
+		t.Errorf("invalid cursor was passed")
+	}
+}
+
+func TestRankCursor(t *testing.T) {
+	createdAt := time.Date(2025, 4, 12, 10, 30, 0, 123456000, time.UTC)
+	id := uuid.New()
+	var rank float32 = 0.0607927
+
+	cursor, err := DecodeRankCursor(EncodeRankCursor(rank, createdAt, id))
+	if err != nil {
+		t.Errorf("ERROR: %v", err)
+	}
+
+	if cursor.Rank != rank || !cursor.CreatedAt.Equal(createdAt) || cursor.ID != id {
+		t.Errorf("invalid cursor was returned")
+	}
+}
+
+func TestRankCursor2(t *testing.T) {
+	// a plain cursor has no rank and can't continue a ranked listing
+	_, err := DecodeRankCursor(EncodeCursor(time.Now(), uuid.New()))
+	if err == nil {
+		t.Errorf("invalid cursor was passed")
+	}
+
+	_, err = DecodeCursor(EncodeRankCursor(1, time.Now(), uuid.New()))
+	if err == nil {
+		t.Errorf("ranked cursor was passed as a plain one")
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("")
+	if err != nil || limit != DefaultLimit {
+		t.Errorf("default limit was not returned")
+	}
+
+	limit, err = ParseLimit("1000")
+	if err != nil || limit != MaxLimit {
+		t.Errorf("limit was not capped")
+	}
+
+	_, err = ParseLimit("-5")
+	if err == nil {
+		t.Errorf("negative limit was passed")
+	}
+}
@@ -0,0 +1,28 @@
+package pagination
+
+import (
+	"errors"
+	"strconv"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+func ParseLimit(rawLimit string) (int32, error) {
+	if rawLimit == "" {
+		return DefaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(rawLimit)
+	if err != nil || limit < 1 {
+		return 0, errors.New("Invalid limit")
+	}
+
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	return int32(limit), nil
+}
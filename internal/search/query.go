@@ -0,0 +1,87 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Query is a parsed search string. Text keeps everything that isn't an operator,
+// quoted phrases included, and is meant for websearch_to_tsquery.
+type Query struct {
+	Text  string
+	From  string
+	Since time.Time
+	Until time.Time
+}
+
+// Parse understands from:<user>, since:<YYYY-MM-DD> and until:<YYYY-MM-DD>.
+// The until date is inclusive, so Until is set to the start of the next day.
+func Parse(rawQuery string) (Query, error) {
+	query := Query{}
+	textParts := []string{}
+
+	for _, token := range splitTokens(rawQuery) {
+		operator, value, found := strings.Cut(token, ":")
+		if !found || strings.HasPrefix(token, `"`) {
+			textParts = append(textParts, token)
+			continue
+		}
+
+		switch strings.ToLower(operator) {
+		case "from":
+			if value == "" {
+				return Query{}, errors.New("Empty from operator")
+			}
+			query.From = value
+		case "since":
+			since, err := time.Parse(dateLayout, value)
+			if err != nil {
+				return Query{}, errors.New("Invalid since date")
+			}
+			query.Since = since
+		case "until":
+			until, err := time.Parse(dateLayout, value)
+			if err != nil {
+				return Query{}, errors.New("Invalid until date")
+			}
+			query.Until = until.AddDate(0, 0, 1)
+		default:
+			textParts = append(textParts, token)
+		}
+	}
+
+	query.Text = strings.Join(textParts, " ")
+
+	return query, nil
+}
+
+// splitTokens splits on whitespace but keeps "quoted phrases" together, quotes included.
+func splitTokens(rawQuery string) []string {
+	tokens := []string{}
+	current := strings.Builder{}
+	inQuotes := false
+
+	for _, ch := range rawQuery {
+		switch {
+		case ch == '"':
+			inQuotes = !inQuotes
+			current.WriteRune(ch)
+		case (ch == ' ' || ch == '\t') && !inQuotes:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(ch)
+		}
+	}
+
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+
+	return tokens
+}
@@ -0,0 +1,47 @@
+package search
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	query, err := Parse(`"hello world" gophers from:alice since:2025-01-01 until:2025-01-31`)
+	if err != nil {
+		t.Errorf("ERROR: %v", err)
+	}
+
+	if query.Text != `"hello world" gophers` {
+		t.Errorf("invalid text: %s", query.Text)
+	}
+
+	if query.From != "alice" {
+		t.Errorf("invalid from: %s", query.From)
+	}
+
+	if !query.Since.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("invalid since: %v", query.Since)
+	}
+
+	if !query.Until.Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("invalid until: %v", query.Until)
+	}
+}
+
+func TestParse2(t *testing.T) {
+	query, err := Parse(`"from:alice is quoted" time: 10:30`)
+	if err != nil {
+		t.Errorf("ERROR: %v", err)
+	}
+
+	if query.From != "" || query.Text != `"from:alice is quoted" time: 10:30` {
+		t.Errorf("operators inside text were parsed")
+	}
+}
+
+func TestParse3(t *testing.T) {
+	_, err := Parse("since:yesterday")
+	if err == nil {
+		t.Errorf("invalid date was passed")
+	}
+}
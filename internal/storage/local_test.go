@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	local, err := NewLocal(t.TempDir(), "/media/")
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	data := []byte("not really a png")
+	key := Key(data, ".png")
+
+	err = local.Put(context.Background(), key, bytes.NewReader(data))
+	if err != nil {
+		t.Errorf("ERROR: %v", err)
+	}
+
+	file, err := local.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+	defer file.Close()
+
+	stored, err := io.ReadAll(file)
+	if err != nil || !bytes.Equal(stored, data) {
+		t.Errorf("invalid blob was returned")
+	}
+
+	if local.URL(key) != "/media/"+key {
+		t.Errorf("invalid url: %s", local.URL(key))
+	}
+}
+
+func TestLocal2(t *testing.T) {
+	local, err := NewLocal(t.TempDir(), "/media")
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	err = local.Put(context.Background(), "../escape.png", bytes.NewReader(nil))
+	if err == nil {
+		t.Errorf("key outside the directory was passed")
+	}
+
+	_, err = local.Get(context.Background(), Key([]byte("missing"), ".png"))
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing blob was found")
+	}
+}
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("Blob not found")
+
+// Storage keeps uploaded blobs. Keys come from Key, so the same bytes always land under the same key.
+type Storage interface {
+	Put(ctx context.Context, key string, data io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// Key returns the content address of data: its SHA-256 in hex plus the given extension.
+func Key(data []byte, extension string) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]) + extension
+}
@@ -0,0 +1,128 @@
+package stream
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before it is dropped.
+const subscriberBuffer = 64
+
+type Event[T any] struct {
+	ID    uint64
+	Value T
+}
+
+type Subscription[T any] struct {
+	events chan Event[T]
+}
+
+// Events is closed when the subscription ends, either by Unsubscribe or because the subscriber fell behind.
+func (s *Subscription[T]) Events() <-chan Event[T] {
+	return s.events
+}
+
+// Hub is an in-process pub/sub. Events get increasing IDs and the last few are kept,
+// so a subscriber that reconnects can pick up where it left off. IDs start over with every
+// hub, Epoch tells the hubs apart.
+type Hub[T any] struct {
+	mu      sync.Mutex
+	epoch   string
+	lastID  uint64
+	backlog []Event[T]
+	size    int
+	subs    map[*Subscription[T]]struct{}
+	closed  bool
+}
+
+func NewHub[T any](backlog int) *Hub[T] {
+	epoch := make([]byte, 8)
+	rand.Read(epoch)
+
+	return &Hub[T]{
+		epoch: hex.EncodeToString(epoch),
+		size:  backlog,
+		subs:  map[*Subscription[T]]struct{}{},
+	}
+}
+
+// Epoch is random for every hub.
+func (h *Hub[T]) Epoch() string {
+	return h.epoch
+}
+
+// Publish sends value to every subscriber and returns the event's ID. It never blocks:
+// a subscriber with a full buffer is dropped and has to resubscribe.
+func (h *Hub[T]) Publish(value T) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	event := Event[T]{ID: h.lastID, Value: value}
+
+	h.backlog = append(h.backlog, event)
+	if len(h.backlog) > h.size {
+		h.backlog = append([]Event[T]{}, h.backlog[len(h.backlog)-h.size:]...)
+	}
+
+	for sub := range h.subs {
+		select {
+		case sub.events <- event:
+		default:
+			delete(h.subs, sub)
+			close(sub.events)
+		}
+	}
+
+	return event.ID
+}
+
+// Subscribe registers a subscriber and returns the kept events published after lastID.
+// A lastID of 0, or one the hub never issued, replays nothing.
+func (h *Hub[T]) Subscribe(lastID uint64) (*Subscription[T], []Event[T]) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := &Subscription[T]{events: make(chan Event[T], subscriberBuffer)}
+	if h.closed {
+		close(sub.events)
+		return sub, []Event[T]{}
+	}
+	h.subs[sub] = struct{}{}
+
+	missed := []Event[T]{}
+	if lastID == 0 || lastID > h.lastID {
+		return sub, missed
+	}
+
+	for _, el := range h.backlog {
+		if el.ID > lastID {
+			missed = append(missed, el)
+		}
+	}
+
+	return sub, missed
+}
+
+func (h *Hub[T]) Unsubscribe(sub *Subscription[T]) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.events)
+	}
+}
+
+// Close ends every subscription, and the ones made after it right away.
+func (h *Hub[T]) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subs {
+		delete(h.subs, sub)
+		close(sub.events)
+	}
+}
@@ -0,0 +1,85 @@
+package stream
+
+import (
+	"testing"
+)
+
+func TestHub(t *testing.T) {
+	hub := NewHub[string](10)
+	sub, missed := hub.Subscribe(0)
+	if len(missed) != 0 {
+		t.Errorf("invalid replay: %v", missed)
+	}
+
+	id := hub.Publish("first")
+	event := <-sub.Events()
+	if event.ID != id || event.Value != "first" {
+		t.Errorf("invalid event: %v", event)
+	}
+
+	hub.Unsubscribe(sub)
+	if _, ok := <-sub.Events(); ok {
+		t.Errorf("events were not closed")
+	}
+}
+
+func TestHub2(t *testing.T) {
+	hub := NewHub[int](3)
+	for i := 1; i <= 5; i++ {
+		hub.Publish(i)
+	}
+
+	_, missed := hub.Subscribe(3)
+	if len(missed) != 2 || missed[0].Value != 4 || missed[1].Value != 5 {
+		t.Errorf("invalid replay: %v", missed)
+	}
+
+	_, missed = hub.Subscribe(1)
+	if len(missed) != 3 || missed[0].Value != 3 {
+		t.Errorf("invalid replay: %v", missed)
+	}
+
+	_, missed = hub.Subscribe(99)
+	if len(missed) != 0 {
+		t.Errorf("invalid replay: %v", missed)
+	}
+}
+
+func TestHub3(t *testing.T) {
+	hub := NewHub[int](1)
+	sub, _ := hub.Subscribe(0)
+	for i := 0; i <= subscriberBuffer; i++ {
+		hub.Publish(i)
+	}
+
+	received := 0
+	for range sub.Events() {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("slow subscriber got %d events", received)
+	}
+}
+
+func TestHub4(t *testing.T) {
+	hub := NewHub[int](10)
+	sub, _ := hub.Subscribe(0)
+
+	hub.Close()
+	if _, ok := <-sub.Events(); ok {
+		t.Errorf("events were not closed")
+	}
+
+	sub, _ = hub.Subscribe(0)
+	if _, ok := <-sub.Events(); ok {
+		t.Errorf("subscribed to a closed hub")
+	}
+
+	hub.Publish(1)
+}
+
+func TestHub5(t *testing.T) {
+	if NewHub[int](1).Epoch() == NewHub[int](1).Epoch() {
+		t.Errorf("hubs share an epoch")
+	}
+}
@@ -0,0 +1,22 @@
+// Package timestamps converts times for the TIMESTAMP columns, which keep no time zone. lib/pq
+// sends a time with its offset and Postgres drops the offset, so a time has to be in UTC, the
+// zone NOW() fills these columns in, before it is stored or compared with one.
+package timestamps
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Column returns t the way it must be stored in a TIMESTAMP column.
+func Column(t time.Time) time.Time {
+	return t.UTC()
+}
+
+// NullColumn is Column for a nullable column, nil is NULL.
+func NullColumn(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: Column(*t), Valid: true}
+}
@@ -0,0 +1,36 @@
+package timestamps
+
+import (
+	"testing"
+	"time"
+)
+
+func TestColumn(t *testing.T) {
+	// 10:00 at +03:00 is 07:00 UTC, storing the wall clock would publish three hours late
+	publishAt, err := time.Parse(time.RFC3339, "2026-01-01T10:00:00+03:00")
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	stored := Column(publishAt)
+	if stored.Location() != time.UTC || stored.Hour() != 7 || !stored.Equal(publishAt) {
+		t.Errorf("invalid column value: %v", stored)
+	}
+}
+
+func TestNullColumn(t *testing.T) {
+	// 10:00 at -05:00 is 15:00 UTC, storing the wall clock would publish five hours early
+	publishAt, err := time.Parse(time.RFC3339, "2026-01-01T10:00:00-05:00")
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	stored := NullColumn(&publishAt)
+	if !stored.Valid || stored.Time.Location() != time.UTC || stored.Time.Hour() != 15 {
+		t.Errorf("invalid column value: %v", stored)
+	}
+
+	if NullColumn(nil).Valid {
+		t.Errorf("nil time was not NULL")
+	}
+}
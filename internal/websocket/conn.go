@@ -0,0 +1,246 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Message types, as the frame opcodes of RFC 6455.
+const (
+	continuationFrame = 0
+	TextMessage       = 1
+	BinaryMessage     = 2
+	CloseMessage      = 8
+	PingMessage       = 9
+	PongMessage       = 10
+)
+
+// Close codes.
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseTryAgainLater   = 1013
+)
+
+const DefaultMaxMessageSize = 64 * 1024
+
+var (
+	ErrClosed          = errors.New("Connection closed")
+	ErrProtocol        = errors.New("Protocol error")
+	ErrMessageTooBig   = errors.New("Message too big")
+	ErrControlTooLarge = errors.New("Control frame payload too large")
+)
+
+// Conn is the server side of a WebSocket connection. Reads must come from one goroutine,
+// writes may come from any.
+type Conn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+
+	writeMu sync.Mutex
+	closeMu sync.Once
+
+	MaxMessageSize int64
+	// PongHandler, when set, is called from ReadMessage for every pong.
+	PongHandler func()
+}
+
+func newConn(conn net.Conn, reader *bufio.Reader) *Conn {
+	return &Conn{
+		conn:           conn,
+		reader:         reader,
+		MaxMessageSize: DefaultMaxMessageSize,
+	}
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// ReadMessage returns the next text or binary message. Pings are answered and a close frame
+// is echoed before ErrClosed is returned.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	messageType := 0
+	message := []byte{}
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			if errors.Is(err, ErrProtocol) {
+				c.Close(CloseProtocolError, "")
+			}
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case PingMessage:
+			err = c.WriteMessage(PongMessage, payload)
+			if err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			if c.PongHandler != nil {
+				c.PongHandler()
+			}
+			continue
+		case CloseMessage:
+			code := CloseNormal
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+			c.Close(code, "")
+			return 0, nil, ErrClosed
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				c.Close(CloseProtocolError, "")
+				return 0, nil, ErrProtocol
+			}
+			messageType = opcode
+		case continuationFrame:
+			if messageType == 0 {
+				c.Close(CloseProtocolError, "")
+				return 0, nil, ErrProtocol
+			}
+		default:
+			c.Close(CloseProtocolError, "")
+			return 0, nil, ErrProtocol
+		}
+
+		if int64(len(message)+len(payload)) > c.MaxMessageSize {
+			c.Close(CloseMessageTooBig, "")
+			return 0, nil, ErrMessageTooBig
+		}
+		message = append(message, payload...)
+
+		if fin {
+			return messageType, message, nil
+		}
+	}
+}
+
+// readFrame reads one frame and unmasks its payload. Clients have to mask every frame.
+func (c *Conn) readFrame() (bool, int, []byte, error) {
+	header := make([]byte, 2)
+	_, err := io.ReadFull(c.reader, header)
+	if err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	opcode := int(header[0] & 0x0f)
+	masked := header[1]&0x80 != 0
+	length := int64(header[1] & 0x7f)
+
+	if header[0]&0x70 != 0 || !masked {
+		return false, 0, nil, ErrProtocol
+	}
+
+	isControl := opcode >= CloseMessage
+	if isControl && (!fin || length > 125) {
+		return false, 0, nil, ErrProtocol
+	}
+
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		_, err = io.ReadFull(c.reader, extended)
+		length = int64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		_, err = io.ReadFull(c.reader, extended)
+		length = int64(binary.BigEndian.Uint64(extended))
+	}
+	if err != nil {
+		return false, 0, nil, err
+	}
+
+	if length < 0 || length > c.MaxMessageSize {
+		c.Close(CloseMessageTooBig, "")
+		return false, 0, nil, ErrMessageTooBig
+	}
+
+	mask := make([]byte, 4)
+	_, err = io.ReadFull(c.reader, mask)
+	if err != nil {
+		return false, 0, nil, err
+	}
+
+	payload := make([]byte, length)
+	_, err = io.ReadFull(c.reader, payload)
+	if err != nil {
+		return false, 0, nil, err
+	}
+
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// WriteMessage sends data as a single unmasked frame.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return c.writeFrame(messageType, data)
+}
+
+// WriteMessageTimeout is WriteMessage that gives up after timeout, so a stalled client can't hold the writer.
+// The deadline is cleared afterwards, untimed writes like the pongs ReadMessage sends must not inherit it.
+func (c *Conn) WriteMessageTimeout(messageType int, data []byte, timeout time.Duration) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(timeout))
+	defer c.conn.SetWriteDeadline(time.Time{})
+	return c.writeFrame(messageType, data)
+}
+
+// Close sends a close frame with code and reason and closes the connection. Only the first call does anything.
+func (c *Conn) Close(code int, reason string) error {
+	err := ErrClosed
+	c.closeMu.Do(func() {
+		payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+		if len(reason) > 123 {
+			reason = reason[:123]
+		}
+		payload = append(payload, reason...)
+
+		c.WriteMessageTimeout(CloseMessage, payload, time.Second)
+		err = c.conn.Close()
+	})
+	return err
+}
+
+// writeFrame expects writeMu to be held.
+func (c *Conn) writeFrame(messageType int, data []byte) error {
+	if messageType >= CloseMessage && len(data) > 125 {
+		return ErrControlTooLarge
+	}
+
+	frame := []byte{0x80 | byte(messageType)}
+	switch {
+	case len(data) <= 125:
+		frame = append(frame, byte(len(data)))
+	case len(data) <= 0xffff:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(data)))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(data)))
+	}
+	frame = append(frame, data...)
+
+	_, err := c.conn.Write(frame)
+	return err
+}
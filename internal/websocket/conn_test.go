@@ -0,0 +1,129 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// clientFrame builds a masked frame the way a browser sends it.
+func clientFrame(fin bool, opcode int, payload []byte) []byte {
+	first := byte(opcode)
+	if fin {
+		first |= 0x80
+	}
+
+	frame := []byte{first, 0x80 | byte(len(payload))}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, el := range payload {
+		frame = append(frame, el^mask[i%4])
+	}
+	return frame
+}
+
+func pipe() (*Conn, net.Conn) {
+	server, client := net.Pipe()
+	return newConn(server, bufio.NewReader(server)), client
+}
+
+func TestAcceptKey(t *testing.T) {
+	// the example from RFC 6455, section 1.3
+	if key := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="); key != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("invalid accept key: %s", key)
+	}
+}
+
+func TestReadMessage(t *testing.T) {
+	conn, client := pipe()
+	go func() {
+		client.Write(clientFrame(false, TextMessage, []byte("hel")))
+		client.Write(clientFrame(true, continuationFrame, []byte("lo")))
+	}()
+
+	messageType, message, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+	if messageType != TextMessage || string(message) != "hello" {
+		t.Errorf("invalid message: %d %q", messageType, message)
+	}
+}
+
+func TestReadMessage2(t *testing.T) {
+	conn, client := pipe()
+	go func() {
+		client.Write(clientFrame(true, PingMessage, []byte("hi")))
+		pong := make([]byte, 4)
+		io.ReadFull(client, pong)
+		if pong[0] != 0x80|PongMessage || string(pong[2:]) != "hi" {
+			t.Errorf("invalid pong: %v", pong)
+		}
+
+		client.Write(clientFrame(true, CloseMessage, binary.BigEndian.AppendUint16(nil, CloseNormal)))
+		io.ReadAll(client)
+	}()
+
+	_, _, err := conn.ReadMessage()
+	if !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestReadMessage3(t *testing.T) {
+	conn, client := pipe()
+	conn.MaxMessageSize = 4
+	go func() {
+		client.Write(clientFrame(true, TextMessage, []byte("too long")))
+		io.ReadAll(client)
+	}()
+
+	_, _, err := conn.ReadMessage()
+	if !errors.Is(err, ErrMessageTooBig) {
+		t.Errorf("expected ErrMessageTooBig, got %v", err)
+	}
+}
+
+func TestWriteMessage(t *testing.T) {
+	conn, client := pipe()
+	go conn.WriteMessage(TextMessage, make([]byte, 300))
+
+	header := make([]byte, 4)
+	io.ReadFull(client, header)
+	if header[0] != 0x80|TextMessage || header[1] != 126 || binary.BigEndian.Uint16(header[2:]) != 300 {
+		t.Errorf("invalid header: %v", header)
+	}
+}
+
+func TestWriteMessage2(t *testing.T) {
+	conn, client := pipe()
+	go func() {
+		io.ReadFull(client, make([]byte, 4))
+
+		// the timed write is long done, the pong must not run into its deadline
+		time.Sleep(100 * time.Millisecond)
+		client.Write(clientFrame(true, PingMessage, []byte("hi")))
+		pong := make([]byte, 4)
+		_, err := io.ReadFull(client, pong)
+		if err != nil || pong[0] != 0x80|PongMessage || string(pong[2:]) != "hi" {
+			t.Errorf("invalid pong: %v %v", pong, err)
+		}
+
+		client.Write(clientFrame(true, CloseMessage, binary.BigEndian.AppendUint16(nil, CloseNormal)))
+		io.ReadAll(client)
+	}()
+
+	err := conn.WriteMessageTimeout(TextMessage, []byte("hi"), 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("ERROR: %v", err)
+	}
+
+	_, _, err = conn.ReadMessage()
+	if !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
@@ -1,23 +1,41 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
 	"sync/atomic"
+	"syscall"
+	"time"
 
+	"github.com/YaroslavalsoraY/Chirpy/internal/activitypub"
 	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/storage"
+	"github.com/YaroslavalsoraY/Chirpy/internal/stream"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
+	db             *sql.DB
 	queries        *database.Queries
 	platform       string
 	secretJWT      string
 	polkaKey       string
+	storage        storage.Storage
+	stream         *stream.Hub[chirpEvent]
+	gateway        *gateway
+	publicURL      string
+	apClient       *activitypub.Client
+	gatewayOrigins []string
 }
 
 func main() {
@@ -35,46 +53,203 @@ func main() {
 
 	polkaApi := os.Getenv("POLKA_KEY")
 
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "media"
+	}
+	mediaStorage, err := storage.NewLocal(mediaDir, os.Getenv("MEDIA_BASE_URL")+"/media")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	chirpRetention := defaultChirpRetention
+	if envRetention := os.Getenv("CHIRP_RETENTION"); envRetention != "" {
+		chirpRetention, err = time.ParseDuration(envRetention)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
 	conf := apiConfig{
 		fileserverHits: atomic.Int32{},
+		db:             db,
 		queries:        database.New(db),
 		platform:       envPlatform,
 		secretJWT:      secretJWT,
 		polkaKey:       polkaApi,
+		storage:        mediaStorage,
+		stream:         stream.NewHub[chirpEvent](streamBacklog),
+		gateway:        newGateway(),
+		publicURL:      strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/"),
+		apClient:       activitypub.NewClient(),
+	}
+	if envOrigins := os.Getenv("GATEWAY_ORIGINS"); envOrigins != "" {
+		conf.gatewayOrigins = strings.Split(envOrigins, ",")
+	}
+
+	// background jobs stop with the server, after the last request is done
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	startWorker := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workersCtx)
+		}()
 	}
 
+	startWorker(func(ctx context.Context) { conf.purgeDeletedChirps(ctx, chirpRetention) })
+	startWorker(conf.publishScheduledChirps)
+	startWorker(conf.deliverActivities)
+
 	baseHandler := http.FileServer(http.Dir("."))
 
 	mux := http.NewServeMux()
 	mux.Handle("/", conf.middlewareMetricsInc(baseHandler))
 	mux.Handle("/app/", conf.middlewareMetricsInc(http.StripPrefix("/app", baseHandler)))
+	mux.HandleFunc("GET /media/{key}", conf.HandlerGetMedia)
+	// the root file server would list the media directory when it lives under the working directory
+	if rel, err := relativeDir(mediaDir); err == nil && filepath.IsLocal(rel) {
+		mux.Handle("/"+filepath.ToSlash(rel)+"/", http.NotFoundHandler())
+		mux.Handle("/app/"+filepath.ToSlash(rel)+"/", http.NotFoundHandler())
+	}
 
 	mux.HandleFunc("GET /api/healthz", HandlerHealtzh)
 	mux.HandleFunc("GET /admin/metrics", conf.HandlerMetrics)
 	mux.HandleFunc("GET /api/chirps", conf.HandlerGetChirps)
+	mux.HandleFunc("GET /api/chirps/scheduled", conf.HandlerGetScheduledChirps)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", conf.HandlerGetOneChirp)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", conf.HandlerGetThread)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/revisions", conf.HandlerGetChirpRevisions)
+	mux.HandleFunc("GET /api/users/{handle}", conf.HandlerGetProfile)
+	mux.HandleFunc("GET /api/users/{userID}/followers", conf.HandlerGetFollowers)
+	mux.HandleFunc("GET /api/users/{userID}/following", conf.HandlerGetFollowing)
+	mux.HandleFunc("GET /api/users/{userID}/likes", conf.HandlerGetUserLikes)
+	mux.HandleFunc("GET /api/users/{userID}/pins", conf.HandlerGetPins)
+	mux.HandleFunc("GET /api/timeline", conf.HandlerTimeline)
+	mux.HandleFunc("GET /api/stream", conf.HandlerStream)
+	mux.HandleFunc("GET /api/gateway", conf.HandlerGateway)
+	mux.HandleFunc("GET /users/{userID}/feed.atom", conf.HandlerUserFeed)
+	mux.HandleFunc("GET /users/{userID}/feed.rss", conf.HandlerUserFeed)
+	mux.HandleFunc("GET /hashtags/{tag}/feed.atom", conf.HandlerHashtagFeed)
+	mux.HandleFunc("GET /hashtags/{tag}/feed.rss", conf.HandlerHashtagFeed)
+	mux.HandleFunc("GET /.well-known/webfinger", conf.HandlerWebFinger)
+	mux.HandleFunc("GET /users/{userID}", conf.HandlerActor)
+	mux.HandleFunc("GET /users/{userID}/outbox", conf.HandlerOutbox)
+	mux.HandleFunc("GET /users/{userID}/followers", conf.HandlerActorFollowers)
+	mux.HandleFunc("GET /chirps/{chirpID}", conf.HandlerNote)
+	mux.HandleFunc("GET /api/search/chirps", conf.HandlerSearchChirps)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", conf.HandlerGetHashtagChirps)
+	mux.HandleFunc("GET /api/trending", conf.HandlerTrending)
+	mux.HandleFunc("GET /api/mentions", conf.HandlerGetMentions)
+	mux.HandleFunc("GET /api/drafts", conf.HandlerGetDrafts)
+	mux.HandleFunc("GET /api/drafts/{draftID}", conf.HandlerGetDraft)
+	mux.HandleFunc("GET /api/bookmarks", conf.HandlerGetBookmarks)
+	mux.HandleFunc("GET /api/collections", conf.HandlerGetCollections)
+	mux.HandleFunc("GET /api/blocks", conf.HandlerGetBlocks)
+	mux.HandleFunc("GET /api/mutes", conf.HandlerGetMutes)
+	mux.HandleFunc("GET /api/notifications", conf.HandlerGetNotifications)
+	mux.HandleFunc("GET /api/notifications/unread", conf.HandlerGetUnreadNotifications)
+	mux.HandleFunc("GET /api/notifications/preferences", conf.HandlerGetNotificationPreferences)
+	mux.HandleFunc("GET /api/conversations", conf.HandlerGetConversations)
+	mux.HandleFunc("GET /api/conversations/unread", conf.HandlerGetUnreadCount)
+	mux.HandleFunc("GET /api/conversations/{conversationID}/messages", conf.HandlerGetMessages)
 
 	mux.HandleFunc("POST /admin/reset", conf.HandlerReset)
 
 	mux.HandleFunc("POST /api/chirps", conf.HandlerCreateChirp)
+	mux.HandleFunc("POST /api/media", conf.HandlerUploadMedia)
+	mux.HandleFunc("POST /api/drafts", conf.HandlerCreateDraft)
 	mux.HandleFunc("POST /api/users", conf.HandlerAddUser)
 	mux.HandleFunc("POST /api/login", conf.HandlerLogin)
 	mux.HandleFunc("POST /api/refresh", conf.HandlerRefresh)
 	mux.HandleFunc("POST /api/revoke", conf.HandlerRevoke)
 	mux.HandleFunc("POST /api/polka/webhooks", conf.HandlerPolka)
+	mux.HandleFunc("POST /api/users/{userID}/follow", conf.HandlerFollow)
+	mux.HandleFunc("POST /api/users/{userID}/block", conf.HandlerBlock)
+	mux.HandleFunc("POST /api/users/{userID}/mute", conf.HandlerMute)
+	mux.HandleFunc("POST /api/conversations", conf.HandlerCreateConversation)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/messages", conf.HandlerSendMessage)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/read", conf.HandlerMarkConversationRead)
+	mux.HandleFunc("POST /api/notifications/read", conf.HandlerMarkAllNotificationsRead)
+	mux.HandleFunc("POST /api/notifications/{notificationID}/read", conf.HandlerMarkNotificationRead)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/like", conf.HandlerLikeChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", conf.HandlerRechirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/poll/votes", conf.HandlerVotePoll)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", conf.HandlerBookmarkChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/pin", conf.HandlerPinChirp)
+	mux.HandleFunc("POST /api/collections", conf.HandlerCreateCollection)
+	mux.HandleFunc("POST /inbox", conf.HandlerInbox)
+	mux.HandleFunc("POST /users/{userID}/inbox", conf.HandlerInbox)
 
 	mux.HandleFunc("PUT /api/users", conf.HandlerUpdateUser)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", conf.HandlerUpdateChirp)
+	mux.HandleFunc("PATCH /api/chirps/{chirpID}", conf.HandlerUpdateChirp)
+	mux.HandleFunc("PUT /api/drafts/{draftID}", conf.HandlerUpdateDraft)
+	mux.HandleFunc("PUT /api/collections/{collectionID}", conf.HandlerRenameCollection)
+	mux.HandleFunc("PUT /api/pins", conf.HandlerReorderPins)
+	mux.HandleFunc("PUT /api/notifications/preferences", conf.HandlerUpdateNotificationPreferences)
 
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", conf.DeleteChirp)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", conf.HandlerUnfollow)
+	mux.HandleFunc("DELETE /api/users/{userID}/block", conf.HandlerUnblock)
+	mux.HandleFunc("DELETE /api/users/{userID}/mute", conf.HandlerUnmute)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/like", conf.HandlerUnlikeChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirp", conf.HandlerUndoRechirp)
+	mux.HandleFunc("DELETE /api/drafts/{draftID}", conf.HandlerDeleteDraft)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", conf.HandlerUnbookmarkChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/pin", conf.HandlerUnpinChirp)
+	mux.HandleFunc("DELETE /api/collections/{collectionID}", conf.HandlerDeleteCollection)
 
 	server := &http.Server{
 		Addr:    ":8080",
 		Handler: mux,
 	}
 
+	// Shutdown waits for handlers to return, which open streams and sockets never do on their own
+	server.RegisterOnShutdown(conf.gateway.shutdown)
+	server.RegisterOnShutdown(conf.stream.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		err := server.Shutdown(shutdownCtx)
+		if err != nil {
+			fmt.Println(err)
+		}
+
+		stopWorkers()
+		workers.Wait()
+	}()
+
 	err = server.ListenAndServe()
-	if err != nil {
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		fmt.Println(err)
 		return
 	}
+
+	<-shutdownDone
+}
+
+// relativeDir returns dir relative to the working directory, which the root file server serves.
+func relativeDir(dir string) (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Rel(wd, abs)
 }
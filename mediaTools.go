@@ -0,0 +1,365 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/auth"
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/imaging"
+	"github.com/YaroslavalsoraY/Chirpy/internal/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	maxMediaBytes    = 5 << 20
+	maxMediaPerChirp = 4
+)
+
+// mediaExtensions maps the content types we can process to the extension blobs are stored with.
+var mediaExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type mediaJson struct {
+	ID          uuid.UUID                `json:"id"`
+	URL         string                   `json:"url"`
+	ContentType string                   `json:"content_type"`
+	SizeBytes   int64                    `json:"size_bytes"`
+	Width       int32                    `json:"width"`
+	Height      int32                    `json:"height"`
+	Blurhash    string                   `json:"blurhash"`
+	Renditions  map[string]renditionJson `json:"renditions"`
+}
+
+type renditionJson struct {
+	URL    string `json:"url"`
+	Width  int32  `json:"width"`
+	Height int32  `json:"height"`
+}
+
+func (cfg *apiConfig) mediaToJson(media database.Medium, renditions []database.MediaRendition) mediaJson {
+	respMedia := mediaJson{
+		ID:          media.ID,
+		URL:         cfg.storage.URL(media.StorageKey),
+		ContentType: media.ContentType,
+		SizeBytes:   media.SizeBytes,
+		Width:       media.Width,
+		Height:      media.Height,
+		Blurhash:    media.Blurhash,
+		Renditions:  map[string]renditionJson{},
+	}
+
+	for _, el := range renditions {
+		respMedia.Renditions[el.Name] = renditionJson{
+			URL:    cfg.storage.URL(el.StorageKey),
+			Width:  el.Width,
+			Height: el.Height,
+		}
+	}
+
+	return respMedia
+}
+
+func (cfg *apiConfig) HandlerUploadMedia(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	// leave some room for the multipart framing around the file
+	r.Body = http.MaxBytesReader(w, r.Body, maxMediaBytes+(1<<20))
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxMediaBytes+1))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if len(data) > maxMediaBytes {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// the client's Content-Type is not trusted, only what the bytes look like
+	if _, ok := mediaExtensions[http.DetectContentType(data)]; !ok {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	processed, err := imaging.Process(data)
+	if errors.Is(err, imaging.ErrUnsupported) {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	if errors.Is(err, imaging.ErrTooLarge) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	originalKey, err := cfg.storeRendition(r.Context(), processed.Original)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	media, err := qtx.InsertMedia(r.Context(), database.InsertMediaParams{
+		UserID:      userID,
+		StorageKey:  originalKey,
+		ContentType: processed.Original.ContentType,
+		SizeBytes:   int64(len(processed.Original.Data)),
+		Width:       int32(processed.Original.Width),
+		Height:      int32(processed.Original.Height),
+		Blurhash:    processed.BlurHash,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	renditions := []database.MediaRendition{}
+	for _, el := range processed.Renditions {
+		key, err := cfg.storeRendition(r.Context(), el)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		rendition := database.InsertMediaRenditionParams{
+			MediaID:     media.ID,
+			Name:        el.Name,
+			StorageKey:  key,
+			ContentType: el.ContentType,
+			Width:       int32(el.Width),
+			Height:      int32(el.Height),
+			SizeBytes:   int64(len(el.Data)),
+		}
+		err = qtx.InsertMediaRendition(r.Context(), rendition)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		renditions = append(renditions, database.MediaRendition(rendition))
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respData, err := json.Marshal(cfg.mediaToJson(media, renditions))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(respData)
+}
+
+// HandlerGetMedia serves one blob. Directories are never listed, and a blob is only served to
+// its uploader or to viewers who can see a chirp it is attached to.
+func (cfg *apiConfig) HandlerGetMedia(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	contentType := ""
+	for mediaType, extension := range mediaExtensions {
+		if strings.HasSuffix(key, extension) {
+			contentType = mediaType
+		}
+	}
+	if contentType == "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	visible, err := cfg.canSeeMedia(r.Context(), cfg.viewerID(r), key)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if !visible {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	blob, err := cfg.storage.Get(r.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer blob.Close()
+
+	// who may see a blob can change, so shared caches must not keep it
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, err = io.Copy(w, blob)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// canSeeMedia checks every upload of the blob, since the same bytes share one key.
+func (cfg *apiConfig) canSeeMedia(ctx context.Context, viewerID uuid.UUID, key string) (bool, error) {
+	owners, err := cfg.queries.GetMediaOwners(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	for _, el := range owners {
+		if el.UserID == viewerID && viewerID != uuid.Nil {
+			return true, nil
+		}
+		if !el.ChirpID.Valid {
+			continue
+		}
+
+		chirp, err := cfg.queries.GetOneChirp(ctx, el.ChirpID.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if chirp.DeletedAt.Valid || (chirp.ScheduledAt.Valid && chirp.UserID != viewerID) {
+			continue
+		}
+
+		visible, err := cfg.canSeeChirp(ctx, viewerID, chirp)
+		if err != nil {
+			return false, err
+		}
+		if visible {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (cfg *apiConfig) storeRendition(ctx context.Context, rendition imaging.Rendition) (string, error) {
+	key := storage.Key(rendition.Data, mediaExtensions[rendition.ContentType])
+	err := cfg.storage.Put(ctx, key, bytes.NewReader(rendition.Data))
+	if err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// attachMedia links uploads to a chirp in the given order. Every upload must belong to the
+// author and must not be attached to another chirp yet.
+func attachMedia(ctx context.Context, q *database.Queries, userID, chirpID uuid.UUID, mediaIDs []uuid.UUID) error {
+	for i, mediaID := range mediaIDs {
+		attached, err := q.AttachMedia(ctx, database.AttachMediaParams{
+			ChirpID:  uuid.NullUUID{UUID: chirpID, Valid: true},
+			Position: int32(i),
+			ID:       mediaID,
+			UserID:   userID,
+		})
+		if err != nil {
+			return err
+		}
+		if attached == 0 {
+			return errMediaUnavailable
+		}
+	}
+
+	return nil
+}
+
+var errMediaUnavailable = errors.New("Media not found or already attached")
+
+func (cfg *apiConfig) fillMedia(ctx context.Context, returnChirps []returnJson) error {
+	chirpIDs := []uuid.UUID{}
+	for _, el := range returnChirps {
+		chirpIDs = append(chirpIDs, el.ID)
+	}
+
+	media, err := cfg.queries.GetMediaForChirps(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+
+	if len(media) == 0 {
+		return nil
+	}
+
+	mediaIDs := []uuid.UUID{}
+	for _, el := range media {
+		mediaIDs = append(mediaIDs, el.ID)
+	}
+
+	renditions, err := cfg.queries.GetMediaRenditions(ctx, mediaIDs)
+	if err != nil {
+		return err
+	}
+
+	renditionsByMedia := map[uuid.UUID][]database.MediaRendition{}
+	for _, el := range renditions {
+		renditionsByMedia[el.MediaID] = append(renditionsByMedia[el.MediaID], el)
+	}
+
+	byChirp := map[uuid.UUID][]mediaJson{}
+	for _, el := range media {
+		byChirp[el.ChirpID.UUID] = append(byChirp[el.ChirpID.UUID], cfg.mediaToJson(el, renditionsByMedia[el.ID]))
+	}
+	for i := range returnChirps {
+		returnChirps[i].Media = byChirp[returnChirps[i].ID]
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/auth"
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/entities"
+	"github.com/google/uuid"
+)
+
+type mentionJson struct {
+	UserID uuid.UUID `json:"user_id"`
+	Handle string    `json:"handle"`
+	Start  int32     `json:"start"`
+	End    int32     `json:"end"`
+}
+
+// saveMentions stores the @handles of a chirp that belong to a user. Unknown handles, and users
+// who blocked the author, stay plain text.
+func saveMentions(ctx context.Context, q *database.Queries, chirp database.Chirp) ([]database.Mention, error) {
+	mentions := entities.Mentions(chirp.Body)
+	if len(mentions) == 0 {
+		return nil, nil
+	}
+
+	handles := []string{}
+	for _, el := range mentions {
+		handles = append(handles, el.Handle)
+	}
+
+	users, err := q.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return nil, err
+	}
+
+	mentionedIDs := []uuid.UUID{}
+	for _, el := range users {
+		mentionedIDs = append(mentionedIDs, el.ID)
+	}
+
+	blockers, err := q.GetBlockersOf(ctx, database.GetBlockersOfParams{
+		BlockedID: chirp.UserID,
+		UserIds:   mentionedIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	blockedBy := map[uuid.UUID]bool{}
+	for _, el := range blockers {
+		blockedBy[el] = true
+	}
+
+	userIDs := map[string]uuid.UUID{}
+	for _, el := range users {
+		if !blockedBy[el.ID] {
+			userIDs[strings.ToLower(el.Handle.String)] = el.ID
+		}
+	}
+
+	saved := []database.Mention{}
+	for _, el := range mentions {
+		userID, ok := userIDs[el.Handle]
+		if !ok {
+			continue
+		}
+
+		mention := database.InsertMentionParams{
+			ChirpID:     chirp.ID,
+			UserID:      userID,
+			Handle:      el.Handle,
+			StartOffset: int32(el.Start),
+			EndOffset:   int32(el.End),
+		}
+		err := q.InsertMention(ctx, mention)
+		if err != nil {
+			return nil, err
+		}
+
+		saved = append(saved, database.Mention(mention))
+	}
+
+	return saved, nil
+}
+
+func (cfg *apiConfig) fillMentions(ctx context.Context, returnChirps []returnJson) error {
+	chirpIDs := []uuid.UUID{}
+	for _, el := range returnChirps {
+		chirpIDs = append(chirpIDs, el.ID)
+	}
+
+	mentions, err := cfg.queries.GetMentionsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+
+	byChirp := map[uuid.UUID][]mentionJson{}
+	for _, el := range mentions {
+		byChirp[el.ChirpID] = append(byChirp[el.ChirpID], mentionJson{
+			UserID: el.UserID,
+			Handle: el.Handle,
+			Start:  el.StartOffset,
+			End:    el.EndOffset,
+		})
+	}
+	for i := range returnChirps {
+		returnChirps[i].Mentions = byChirp[returnChirps[i].ID]
+	}
+
+	return nil
+}
+
+func (cfg *apiConfig) HandlerGetMentions(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	chirps, err := cfg.queries.ListMentionChirps(r.Context(), database.ListMentionChirpsParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		MaxRows:         page.limit + 1,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	resp := chirpsPage{}
+	chirps, resp.NextCursor = trimChirpsPage(chirps, page.limit)
+	resp.Chirps, err = cfg.chirpsToJson(r.Context(), userID, chirps)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respData, err := json.Marshal(resp)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
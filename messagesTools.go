@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/auth"
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const maxMessageLength = 1000
+
+type message struct {
+	Body string `json:"body"`
+}
+
+type messageJson struct {
+	ID             uuid.UUID   `json:"id"`
+	CreatedAt      time.Time   `json:"created_at"`
+	ConversationID uuid.UUID   `json:"conversation_id"`
+	SenderID       uuid.UUID   `json:"sender_id"`
+	Body           string      `json:"body"`
+	ReadBy         []uuid.UUID `json:"read_by"`
+}
+
+type messagesPage struct {
+	Messages   []messageJson `json:"messages"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// messageToJson fills in the read receipts: every other member who has read up to the message.
+func messageToJson(msg database.Message, members []database.ConversationMember) messageJson {
+	respMessage := messageJson{
+		ID:             msg.ID,
+		CreatedAt:      msg.CreatedAt,
+		ConversationID: msg.ConversationID,
+		SenderID:       msg.SenderID,
+		Body:           msg.Body,
+		ReadBy:         []uuid.UUID{},
+	}
+
+	for _, el := range members {
+		if el.UserID != msg.SenderID && !el.LastReadAt.Before(msg.CreatedAt) {
+			respMessage.ReadBy = append(respMessage.ReadBy, el.UserID)
+		}
+	}
+
+	return respMessage
+}
+
+// blockedInConversation reports whether userID may not write to the conversation. A direct conversation
+// goes quiet once either side blocks the other, a group only stops users one of the members blocked.
+func (cfg *apiConfig) blockedInConversation(ctx context.Context, userID uuid.UUID, conversation database.Conversation, members []database.ConversationMember) (bool, error) {
+	if !conversation.IsDirect {
+		return cfg.queries.IsBlockedInConversation(ctx, database.IsBlockedInConversationParams{
+			ConversationID: conversation.ID,
+			UserID:         userID,
+		})
+	}
+
+	otherIDs := []uuid.UUID{}
+	for _, el := range members {
+		if el.UserID != userID {
+			otherIDs = append(otherIDs, el.UserID)
+		}
+	}
+
+	return cfg.queries.HasBlockWithAny(ctx, database.HasBlockWithAnyParams{
+		UserID:   userID,
+		OtherIds: otherIDs,
+	})
+}
+
+func (cfg *apiConfig) HandlerSendMessage(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	newMessage := message{}
+	err = decoder.Decode(&newMessage)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if newMessage.Body == "" || utf8.RuneCountInString(newMessage.Body) > maxMessageLength {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	conversation, err := cfg.queries.GetConversationForMember(r.Context(), database.GetConversationForMemberParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	members, err := cfg.queries.GetConversationMembers(r.Context(), []uuid.UUID{conversationID})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	blocked, err := cfg.blockedInConversation(r.Context(), userID, conversation, members)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if blocked {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	savedMessage, err := qtx.InsertMessage(r.Context(), database.InsertMessageParams{
+		ConversationID: conversationID,
+		SenderID:       userID,
+		Body:           newMessage.Body,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = qtx.TouchConversation(r.Context(), conversationID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = qtx.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respMessage := messageToJson(savedMessage, members)
+
+	memberIDs := []uuid.UUID{}
+	for _, el := range members {
+		memberIDs = append(memberIDs, el.UserID)
+	}
+	cfg.gateway.send(memberIDs, gatewayMessage, respMessage)
+
+	respData, err := json.Marshal(respMessage)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(respData)
+}
+
+func (cfg *apiConfig) HandlerGetMessages(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.queries.GetConversationForMember(r.Context(), database.GetConversationForMemberParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	messages, err := cfg.queries.ListMessages(r.Context(), database.ListMessagesParams{
+		ConversationID:  conversationID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		MaxRows:         page.limit + 1,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	members, err := cfg.queries.GetConversationMembers(r.Context(), []uuid.UUID{conversationID})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	resp := messagesPage{
+		Messages: []messageJson{},
+	}
+	if len(messages) > int(page.limit) {
+		messages = messages[:page.limit]
+		last := messages[len(messages)-1]
+		resp.NextCursor = pagination.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	for _, el := range messages {
+		resp.Messages = append(resp.Messages, messageToJson(el, members))
+	}
+
+	respData, err := json.Marshal(resp)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/auth"
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const (
+	notificationReply   = "reply"
+	notificationLike    = "like"
+	notificationFollow  = "follow"
+	notificationMention = "mention"
+)
+
+var notificationTypes = []string{notificationReply, notificationLike, notificationFollow, notificationMention}
+
+// maxNotificationActors is how many actors a grouped notification lists, the rest only count.
+const maxNotificationActors = 3
+
+type notificationJson struct {
+	ID         uuid.UUID   `json:"id"`
+	Type       string      `json:"type"`
+	CreatedAt  time.Time   `json:"created_at"`
+	ChirpID    *uuid.UUID  `json:"chirp_id,omitempty"`
+	ActorIDs   []uuid.UUID `json:"actor_ids"`
+	ActorCount int64       `json:"actor_count"`
+	Read       bool        `json:"read"`
+}
+
+type notificationsPage struct {
+	Notifications []notificationJson `json:"notifications"`
+	NextCursor    string             `json:"next_cursor,omitempty"`
+}
+
+// notify records that actorID did something to userID and returns the stored notification, if any.
+// The query drops notifications to oneself, between blocked or muted users, of a type the user
+// turned off, and repeats.
+func notify(ctx context.Context, q *database.Queries, userID, actorID uuid.UUID, kind string, chirpID uuid.NullUUID) ([]database.Notification, error) {
+	return q.InsertNotification(ctx, database.InsertNotificationParams{
+		UserID:  userID,
+		ActorID: actorID,
+		Type:    kind,
+		ChirpID: chirpID,
+	})
+}
+
+// notifyChirp tells the parent's author about a published reply and the mentioned users
+// about their mention. A parent author mentioned in the reply only gets the reply.
+func notifyChirp(ctx context.Context, q *database.Queries, chirp database.Chirp, mentions []database.Mention) ([]database.Notification, error) {
+	chirpID := uuid.NullUUID{UUID: chirp.ID, Valid: true}
+	notifications := []database.Notification{}
+
+	parentAuthor := uuid.Nil
+	if chirp.InReplyTo.Valid {
+		parent, err := q.GetOneChirp(ctx, chirp.InReplyTo.UUID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+
+		// a scheduled reply can outlive its parent, a purged or deleted parent has nobody to tell
+		if err == nil && !parent.DeletedAt.Valid {
+			parentAuthor = parent.UserID
+
+			saved, err := notify(ctx, q, parent.UserID, chirp.UserID, notificationReply, chirpID)
+			if err != nil {
+				return nil, err
+			}
+			notifications = append(notifications, saved...)
+		}
+	}
+
+	for _, el := range mentions {
+		if el.UserID == parentAuthor {
+			continue
+		}
+
+		saved, err := notify(ctx, q, el.UserID, chirp.UserID, notificationMention, chirpID)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, saved...)
+	}
+
+	return notifications, nil
+}
+
+func notificationToJson(notification database.Notification) notificationJson {
+	respNotification := notificationJson{
+		ID:         notification.ID,
+		Type:       notification.Type,
+		CreatedAt:  notification.CreatedAt,
+		ActorIDs:   []uuid.UUID{notification.ActorID},
+		ActorCount: 1,
+		Read:       notification.ReadAt.Valid,
+	}
+	if notification.ChirpID.Valid {
+		respNotification.ChirpID = &notification.ChirpID.UUID
+	}
+
+	return respNotification
+}
+
+func (cfg *apiConfig) HandlerGetNotifications(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	groups, err := cfg.queries.ListNotificationGroups(r.Context(), database.ListNotificationGroupsParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		MaxRows:         page.limit + 1,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	resp := notificationsPage{
+		Notifications: []notificationJson{},
+	}
+	if len(groups) > int(page.limit) {
+		groups = groups[:page.limit]
+		last := groups[len(groups)-1]
+		resp.NextCursor = pagination.EncodeCursor(last.LatestAt, last.ID)
+	}
+
+	groupKeys := []string{}
+	for _, el := range groups {
+		groupKeys = append(groupKeys, el.GroupKey)
+	}
+
+	actors, err := cfg.queries.GetNotificationActors(r.Context(), database.GetNotificationActorsParams{
+		UserID:    userID,
+		GroupKeys: groupKeys,
+		MaxActors: maxNotificationActors,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	actorsByGroup := map[string][]uuid.UUID{}
+	for _, el := range actors {
+		actorsByGroup[el.GroupKey] = append(actorsByGroup[el.GroupKey], el.ActorID)
+	}
+
+	for _, el := range groups {
+		respNotification := notificationJson{
+			ID:         el.ID,
+			Type:       el.Type,
+			CreatedAt:  el.LatestAt,
+			ActorIDs:   []uuid.UUID{},
+			ActorCount: el.ActorCount,
+			Read:       el.Read,
+		}
+		if el.ChirpID.Valid {
+			respNotification.ChirpID = &el.ChirpID.UUID
+		}
+		respNotification.ActorIDs = append(respNotification.ActorIDs, actorsByGroup[el.GroupKey]...)
+
+		resp.Notifications = append(resp.Notifications, respNotification)
+	}
+
+	respData, err := json.Marshal(resp)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
+
+func (cfg *apiConfig) HandlerGetUnreadNotifications(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	count, err := cfg.queries.CountUnreadNotifications(r.Context(), userID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respData, err := json.Marshal(unreadCount{UnreadCount: count})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
+
+// HandlerMarkNotificationRead marks the whole group the notification is listed in as read.
+func (cfg *apiConfig) HandlerMarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	notificationID, err := uuid.Parse(r.PathValue("notificationID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	notification, err := cfg.queries.GetNotification(r.Context(), database.GetNotificationParams{
+		ID:     notificationID,
+		UserID: userID,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	err = cfg.queries.MarkNotificationGroupRead(r.Context(), database.MarkNotificationGroupReadParams{
+		UserID:   userID,
+		GroupKey: notification.GroupKey,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) HandlerMarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	err = cfg.queries.MarkAllNotificationsRead(r.Context(), userID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// notificationPreferences maps every notification type to whether the user wants it.
+// Types the user never touched are on.
+func (cfg *apiConfig) notificationPreferences(ctx context.Context, userID uuid.UUID) (map[string]bool, error) {
+	prefs, err := cfg.queries.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	returnPrefs := map[string]bool{}
+	for _, el := range notificationTypes {
+		returnPrefs[el] = true
+	}
+	for _, el := range prefs {
+		returnPrefs[el.Type] = el.Enabled
+	}
+
+	return returnPrefs, nil
+}
+
+func (cfg *apiConfig) HandlerGetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	prefs, err := cfg.notificationPreferences(r.Context(), userID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respData, err := json.Marshal(prefs)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
+
+// HandlerUpdateNotificationPreferences takes a {"type": enabled} object. Types left out keep their setting.
+func (cfg *apiConfig) HandlerUpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := map[string]bool{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	prefs, err := cfg.notificationPreferences(r.Context(), userID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	for kind := range params {
+		if _, ok := prefs[kind]; !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	for kind, enabled := range params {
+		err = qtx.SetNotificationPreference(r.Context(), database.SetNotificationPreferenceParams{
+			UserID:  userID,
+			Type:    kind,
+			Enabled: enabled,
+		})
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		prefs[kind] = enabled
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respData, err := json.Marshal(prefs)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"net/url"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+type pageParams struct {
+	limit  int32
+	cursor *pagination.Cursor
+}
+
+func parsePageParams(query url.Values) (pageParams, error) {
+	page := pageParams{}
+
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		return pageParams{}, err
+	}
+	page.limit = limit
+
+	if rawCursor := query.Get("cursor"); rawCursor != "" {
+		cursor, err := pagination.DecodeCursor(rawCursor)
+		if err != nil {
+			return pageParams{}, err
+		}
+		page.cursor = &cursor
+	}
+
+	return page, nil
+}
+
+func (p pageParams) cursorCreatedAt() sql.NullTime {
+	if p.cursor == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: p.cursor.CreatedAt, Valid: true}
+}
+
+func (p pageParams) cursorID() uuid.NullUUID {
+	if p.cursor == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: p.cursor.ID, Valid: true}
+}
+
+func trimChirpsPage(chirps []database.Chirp, limit int32) ([]database.Chirp, string) {
+	if len(chirps) <= int(limit) {
+		return chirps, ""
+	}
+
+	chirps = chirps[:limit]
+	last := chirps[len(chirps)-1]
+	return chirps, pagination.EncodeCursor(last.CreatedAt, last.ID)
+}
+
+// nextPageLink is a Link header value pointing at the page after this request's.
+func nextPageLink(r *http.Request, nextCursor string) string {
+	query := r.URL.Query()
+	query.Set("cursor", nextCursor)
+	next := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	return "<" + next.String() + `>; rel="next"`
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/auth"
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxPins          = 3
+	maxPinsChirpyRed = 10
+)
+
+type pinOrder struct {
+	ChirpIDs []uuid.UUID `json:"chirp_ids"`
+}
+
+func pinLimit(user database.User) int {
+	if user.IsChirpyRed.Bool {
+		return maxPinsChirpyRed
+	}
+	return maxPins
+}
+
+// withPinnedChirps moves the author's pinned chirps out of the feed and, on the first page,
+// puts them in front marked as pinned, so every chirp shows up exactly once.
+func (cfg *apiConfig) withPinnedChirps(ctx context.Context, viewerID, authorID uuid.UUID, firstPage bool, returnChirps []returnJson) ([]returnJson, error) {
+	rows, err := cfg.queries.ListPinnedChirps(ctx, database.ListPinnedChirpsParams{
+		UserID:   authorID,
+		ViewerID: nullUUID(viewerID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return returnChirps, nil
+	}
+
+	pinnedIDs := map[uuid.UUID]bool{}
+	pinnedChirps := []database.Chirp{}
+	for _, el := range rows {
+		pinnedIDs[el.Chirp.ID] = true
+		pinnedChirps = append(pinnedChirps, el.Chirp)
+	}
+
+	feed := []returnJson{}
+	if firstPage {
+		feed, err = cfg.chirpsToJson(ctx, viewerID, pinnedChirps)
+		if err != nil {
+			return nil, err
+		}
+		for i := range feed {
+			feed[i].Pinned = true
+		}
+	}
+
+	for _, el := range returnChirps {
+		if !pinnedIDs[el.ID] {
+			feed = append(feed, el)
+		}
+	}
+
+	return feed, nil
+}
+
+func (cfg *apiConfig) HandlerPinChirp(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	chirp, err := cfg.queries.GetOneChirp(r.Context(), chirpID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if chirp.DeletedAt.Valid {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+
+	if chirp.UserID != userID {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if chirp.ScheduledAt.Valid {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	// locking the user makes concurrent pins wait for each other, so they can't both pass the limit
+	user, err := qtx.GetUserForUpdate(r.Context(), userID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	pinnedIDs, err := qtx.GetPinnedChirpIDs(r.Context(), userID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	for _, el := range pinnedIDs {
+		if el == chirpID {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	if len(pinnedIDs) >= pinLimit(user) {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	err = qtx.PinChirp(r.Context(), database.PinChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) HandlerUnpinChirp(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = cfg.queries.UnpinChirp(r.Context(), database.UnpinChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerReorderPins takes the caller's pinned chirp IDs in their new order.
+// The list has to contain exactly the chirps that are pinned right now.
+func (cfg *apiConfig) HandlerReorderPins(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	order := pinOrder{}
+	err = decoder.Decode(&order)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	pinnedIDs, err := qtx.GetPinnedChirpIDs(r.Context(), userID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	pinned := map[uuid.UUID]bool{}
+	for _, el := range pinnedIDs {
+		pinned[el] = true
+	}
+
+	seen := map[uuid.UUID]bool{}
+	for _, el := range order.ChirpIDs {
+		if !pinned[el] || seen[el] {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		seen[el] = true
+	}
+	if len(seen) != len(pinned) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	for i, el := range order.ChirpIDs {
+		err = qtx.SetPinPosition(r.Context(), database.SetPinPositionParams{
+			Position: int32(i),
+			UserID:   userID,
+			ChirpID:  el,
+		})
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) HandlerGetPins(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	returnChirps, err := cfg.withPinnedChirps(r.Context(), cfg.viewerID(r), userID, true, nil)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if returnChirps == nil {
+		returnChirps = []returnJson{}
+	}
+
+	respData, err := json.Marshal(returnChirps)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/auth"
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/timestamps"
+	"github.com/google/uuid"
+)
+
+const (
+	minPollOptions   = 2
+	maxPollOptions   = 4
+	maxPollOptionLen = 25
+	maxPollDuration  = 7 * 24 * time.Hour
+)
+
+type pollInput struct {
+	Options  []string  `json:"options"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+type pollJson struct {
+	ClosesAt   time.Time        `json:"closes_at"`
+	Closed     bool             `json:"closed"`
+	Options    []pollOptionJson `json:"options"`
+	TotalVotes *int64           `json:"total_votes,omitempty"`
+	MyVote     *uuid.UUID       `json:"my_vote,omitempty"`
+}
+
+// pollOptionJson leaves Votes out while the caller is not allowed to see the results.
+type pollOptionJson struct {
+	ID    uuid.UUID `json:"id"`
+	Label string    `json:"label"`
+	Votes *int64    `json:"votes,omitempty"`
+}
+
+type pollVote struct {
+	OptionID uuid.UUID `json:"option_id"`
+}
+
+// validatePoll returns the reason a poll can't be created, or "" if it is fine.
+// opensAt is when the chirp becomes visible, so scheduled chirps keep the full window.
+func validatePoll(poll pollInput, opensAt time.Time) string {
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		return fmt.Sprintf("Poll needs %d to %d options", minPollOptions, maxPollOptions)
+	}
+
+	seen := map[string]bool{}
+	for _, el := range poll.Options {
+		option := strings.ToLower(strings.TrimSpace(el))
+		if option == "" || utf8.RuneCountInString(option) > maxPollOptionLen {
+			return fmt.Sprintf("Poll options must be 1 to %d characters", maxPollOptionLen)
+		}
+		if seen[option] {
+			return "Poll options must be unique"
+		}
+		seen[option] = true
+	}
+
+	if !poll.ClosesAt.After(opensAt) {
+		return "Poll must close in the future"
+	}
+	if poll.ClosesAt.Sub(opensAt) > maxPollDuration {
+		return "Poll can't stay open for more than 7 days"
+	}
+
+	return ""
+}
+
+func savePoll(ctx context.Context, q *database.Queries, chirpID uuid.UUID, poll pollInput) error {
+	err := q.InsertPoll(ctx, database.InsertPollParams{
+		ChirpID:  chirpID,
+		ClosesAt: timestamps.Column(poll.ClosesAt),
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, el := range poll.Options {
+		err := q.InsertPollOption(ctx, database.InsertPollOptionParams{
+			ChirpID:  chirpID,
+			Position: int32(i),
+			Label:    strings.TrimSpace(el),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fillPolls attaches polls to the chirps that have one. Tallies are only shown once the
+// viewer has voted or the poll has closed, so they can't sway anyone's vote.
+func (cfg *apiConfig) fillPolls(ctx context.Context, viewerID uuid.UUID, returnChirps []returnJson) error {
+	chirpIDs := []uuid.UUID{}
+	for _, el := range returnChirps {
+		chirpIDs = append(chirpIDs, el.ID)
+	}
+
+	polls, err := cfg.queries.GetPollsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+
+	if len(polls) == 0 {
+		return nil
+	}
+
+	pollIDs := []uuid.UUID{}
+	for _, el := range polls {
+		pollIDs = append(pollIDs, el.ChirpID)
+	}
+
+	options, err := cfg.queries.GetPollOptionsForChirps(ctx, pollIDs)
+	if err != nil {
+		return err
+	}
+
+	voteCounts, err := cfg.queries.CountPollVotes(ctx, pollIDs)
+	if err != nil {
+		return err
+	}
+
+	counts := map[uuid.UUID]int64{}
+	for _, el := range voteCounts {
+		counts[el.OptionID] = el.VoteCount
+	}
+
+	myVotes := map[uuid.UUID]uuid.UUID{}
+	if viewerID != uuid.Nil {
+		votes, err := cfg.queries.GetPollVotesByUser(ctx, database.GetPollVotesByUserParams{
+			UserID:   viewerID,
+			ChirpIds: pollIDs,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, el := range votes {
+			myVotes[el.ChirpID] = el.OptionID
+		}
+	}
+
+	optionsByPoll := map[uuid.UUID][]database.PollOption{}
+	for _, el := range options {
+		optionsByPoll[el.ChirpID] = append(optionsByPoll[el.ChirpID], el)
+	}
+
+	byChirp := map[uuid.UUID]*pollJson{}
+	for _, el := range polls {
+		respPoll := &pollJson{
+			ClosesAt: el.ClosesAt,
+			Closed:   !el.ClosesAt.After(time.Now()),
+			Options:  []pollOptionJson{},
+		}
+
+		myVote, voted := myVotes[el.ChirpID]
+		if voted {
+			respPoll.MyVote = &myVote
+		}
+		showResults := voted || respPoll.Closed
+
+		total := int64(0)
+		for _, option := range optionsByPoll[el.ChirpID] {
+			respOption := pollOptionJson{
+				ID:    option.ID,
+				Label: option.Label,
+			}
+			if showResults {
+				votes := counts[option.ID]
+				respOption.Votes = &votes
+				total += votes
+			}
+			respPoll.Options = append(respPoll.Options, respOption)
+		}
+		if showResults {
+			respPoll.TotalVotes = &total
+		}
+
+		byChirp[el.ChirpID] = respPoll
+	}
+
+	for i := range returnChirps {
+		returnChirps[i].Poll = byChirp[returnChirps[i].ID]
+	}
+
+	return nil
+}
+
+func (cfg *apiConfig) HandlerVotePoll(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	vote := pollVote{}
+	err = decoder.Decode(&vote)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	chirp, err := cfg.queries.GetOneChirp(r.Context(), chirpID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if chirp.ScheduledAt.Valid {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	visible, err := cfg.canSeeChirp(r.Context(), userID, chirp)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if !visible {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if chirp.DeletedAt.Valid {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+
+	poll, err := cfg.queries.GetPoll(r.Context(), chirpID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if !poll.ClosesAt.After(time.Now()) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	options, err := cfg.queries.GetPollOptionsForChirps(r.Context(), []uuid.UUID{chirpID})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	validOption := false
+	for _, el := range options {
+		if el.ID == vote.OptionID {
+			validOption = true
+		}
+	}
+	if !validOption {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = cfg.queries.InsertPollVote(r.Context(), database.InsertPollVoteParams{
+		ChirpID:  chirpID,
+		UserID:   userID,
+		OptionID: vote.OptionID,
+	})
+	if isUniqueViolation(err) {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respChirps, err := cfg.chirpsToJson(r.Context(), userID, []database.Chirp{chirp})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respData, err := json.Marshal(respChirps[0].Poll)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(respData)
+}
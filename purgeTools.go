@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/timestamps"
+)
+
+const (
+	defaultChirpRetention = 30 * 24 * time.Hour
+	purgeInterval         = time.Hour
+)
+
+// purgeDeletedChirps hard-deletes soft-deleted chirps once they are older than retention.
+// It runs until ctx is cancelled.
+func (cfg *apiConfig) purgeDeletedChirps(ctx context.Context, retention time.Duration) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		cutoff := time.Now().Add(-retention)
+		err := cfg.queries.PurgeDeletedChirps(ctx, timestamps.NullColumn(&cutoff))
+		if err != nil {
+			fmt.Println(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
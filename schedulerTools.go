@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/auth"
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+)
+
+const (
+	publishInterval  = 10 * time.Second
+	publishBatchSize = 100
+)
+
+// publishScheduledChirps publishes chirps whose scheduled time has passed until ctx is cancelled.
+// The schedule lives only in the chirps table, so chirps that came due while the server was
+// down go out on the first pass after a restart.
+func (cfg *apiConfig) publishScheduledChirps(ctx context.Context) {
+	ticker := time.NewTicker(publishInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			published, err := cfg.publishDueChirps(ctx)
+			if err != nil {
+				fmt.Println(err)
+				break
+			}
+			if published < publishBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishDueChirps publishes one batch of due chirps and returns how many it published.
+// The rows are locked with SKIP LOCKED, so several instances never publish the same chirp.
+// A batch cut off by ctx rolls back as a whole and is published again on the next start.
+// Each chirp is published under its own savepoint, so one that fails stays scheduled without
+// taking the rest of the batch down with it.
+func (cfg *apiConfig) publishDueChirps(ctx context.Context) (int, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	due, err := qtx.GetDueChirps(ctx, publishBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	publishedChirps := []database.Chirp{}
+	notifications := []database.Notification{}
+	for _, el := range due {
+		_, err = tx.ExecContext(ctx, "SAVEPOINT publish_chirp")
+		if err != nil {
+			return 0, err
+		}
+
+		published, saved, err := publishChirp(ctx, qtx, el)
+		if err != nil {
+			fmt.Println(err)
+			_, err = tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT publish_chirp")
+			if err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT publish_chirp")
+		if err != nil {
+			return 0, err
+		}
+		publishedChirps = append(publishedChirps, published)
+		notifications = append(notifications, saved...)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, el := range publishedChirps {
+		cfg.publishChirpEvent(ctx, streamCreated, el)
+		// without a request to take the host from, scheduled chirps only federate with PUBLIC_URL set
+		cfg.federateChirp(ctx, cfg.publicURL, "Create", el)
+	}
+	cfg.gateway.pushNotifications(notifications)
+
+	return len(publishedChirps), nil
+}
+
+func publishChirp(ctx context.Context, q *database.Queries, chirp database.Chirp) (database.Chirp, []database.Notification, error) {
+	published, err := q.PublishChirp(ctx, chirp.ID)
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	err = saveHashtags(ctx, q, published)
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	mentions, err := saveMentions(ctx, q, published)
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	notifications, err := notifyChirp(ctx, q, published, mentions)
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	return published, notifications, nil
+}
+
+func (cfg *apiConfig) HandlerGetScheduledChirps(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secretJWT)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	chirps, err := cfg.queries.ListScheduledChirps(r.Context(), userID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	returnChirps, err := cfg.chirpsToJson(r.Context(), userID, chirps)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respData, err := json.Marshal(returnChirps)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/pagination"
+	"github.com/YaroslavalsoraY/Chirpy/internal/search"
+	"github.com/google/uuid"
+)
+
+// searchHit.Snippet is HTML: the escaped chirp body with matches wrapped in <mark>.
+type searchHit struct {
+	Chirp   returnJson `json:"chirp"`
+	Snippet string     `json:"snippet"`
+	Rank    float32    `json:"rank"`
+}
+
+type searchPage struct {
+	Results    []searchHit `json:"results"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+func (cfg *apiConfig) HandlerSearchChirps(w http.ResponseWriter, r *http.Request) {
+	query, err := search.Parse(r.URL.Query().Get("q"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	authorID := uuid.NullUUID{}
+	if query.From != "" {
+		parsedID, err := uuid.Parse(query.From)
+		if err != nil {
+			author, err := cfg.queries.GetUserByHandle(r.Context(), strings.TrimPrefix(query.From, "@"))
+			if err != nil {
+				fmt.Println(err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			parsedID = author.ID
+		}
+		authorID = uuid.NullUUID{UUID: parsedID, Valid: true}
+	}
+
+	since := sql.NullTime{Time: query.Since, Valid: !query.Since.IsZero()}
+	until := sql.NullTime{Time: query.Until, Valid: !query.Until.IsZero()}
+
+	sortingMethod := r.URL.Query().Get("sort")
+	if sortingMethod == "" {
+		sortingMethod = "relevance"
+		if query.Text == "" {
+			sortingMethod = "recency"
+		}
+	}
+
+	// relevance pages are ordered by rank first, so their cursor carries the rank as well
+	page := pageParams{}
+	var rankCursor *pagination.RankCursor
+	if sortingMethod == "relevance" {
+		page.limit, err = pagination.ParseLimit(r.URL.Query().Get("limit"))
+		if rawCursor := r.URL.Query().Get("cursor"); err == nil && rawCursor != "" {
+			var cursor pagination.RankCursor
+			cursor, err = pagination.DecodeRankCursor(rawCursor)
+			rankCursor = &cursor
+		}
+	} else {
+		page, err = parsePageParams(r.URL.Query())
+	}
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+
+	chirps := []database.Chirp{}
+	resp := searchPage{
+		Results: []searchHit{},
+	}
+
+	switch sortingMethod {
+	case "relevance":
+		arg := database.SearchChirpsByRelevanceParams{
+			Query:    query.Text,
+			ViewerID: nullUUID(viewerID),
+			AuthorID: authorID,
+			Since:    since,
+			Until:    until,
+			MaxRows:  page.limit + 1,
+		}
+		if rankCursor != nil {
+			arg.CursorCreatedAt = sql.NullTime{Time: rankCursor.CreatedAt, Valid: true}
+			arg.CursorRank = sql.NullFloat64{Float64: float64(rankCursor.Rank), Valid: true}
+			arg.CursorID = uuid.NullUUID{UUID: rankCursor.ID, Valid: true}
+		}
+
+		rows, err := cfg.queries.SearchChirpsByRelevance(r.Context(), arg)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if len(rows) > int(page.limit) {
+			rows = rows[:page.limit]
+			last := rows[len(rows)-1]
+			resp.NextCursor = pagination.EncodeRankCursor(last.Rank, last.Chirp.CreatedAt, last.Chirp.ID)
+		}
+
+		for _, el := range rows {
+			chirps = append(chirps, el.Chirp)
+			resp.Results = append(resp.Results, searchHit{Snippet: el.Snippet, Rank: el.Rank})
+		}
+	case "recency":
+		rows, err := cfg.queries.SearchChirpsByRecency(r.Context(), database.SearchChirpsByRecencyParams{
+			Query:           query.Text,
+			ViewerID:        nullUUID(viewerID),
+			AuthorID:        authorID,
+			Since:           since,
+			Until:           until,
+			CursorCreatedAt: page.cursorCreatedAt(),
+			CursorID:        page.cursorID(),
+			MaxRows:         page.limit + 1,
+		})
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if len(rows) > int(page.limit) {
+			rows = rows[:page.limit]
+			last := rows[len(rows)-1]
+			resp.NextCursor = pagination.EncodeCursor(last.Chirp.CreatedAt, last.Chirp.ID)
+		}
+
+		for _, el := range rows {
+			chirps = append(chirps, el.Chirp)
+			resp.Results = append(resp.Results, searchHit{Snippet: el.Snippet, Rank: el.Rank})
+		}
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	returnChirps, err := cfg.chirpsToJson(r.Context(), viewerID, chirps)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	for i := range resp.Results {
+		resp.Results[i].Chirp = returnChirps[i]
+	}
+
+	respData, err := json.Marshal(resp)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
@@ -0,0 +1,132 @@
+-- name: GetActorKey :one
+SELECT * FROM actor_keys
+WHERE user_id = $1;
+
+-- name: InsertActorKey :exec
+INSERT INTO actor_keys(user_id, created_at, public_key_pem, private_key_pem)
+VALUES (
+    $1,
+    NOW(),
+    $2,
+    $3
+)
+ON CONFLICT (user_id) DO NOTHING;
+
+-- name: UpsertRemoteActor :one
+INSERT INTO remote_actors(id, uri, username, inbox, shared_inbox, key_id, public_key_pem, fetched_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    NOW()
+)
+ON CONFLICT (uri) DO UPDATE
+SET username = EXCLUDED.username,
+    inbox = EXCLUDED.inbox,
+    shared_inbox = EXCLUDED.shared_inbox,
+    key_id = EXCLUDED.key_id,
+    public_key_pem = EXCLUDED.public_key_pem,
+    fetched_at = NOW()
+WHERE substring(EXCLUDED.key_id from '^[a-z]+://[^/?#]+') = substring(remote_actors.uri from '^[a-z]+://[^/?#]+')
+RETURNING *;
+
+-- name: GetRemoteActorByKeyID :one
+SELECT * FROM remote_actors
+WHERE key_id = $1;
+
+-- name: InsertRemoteFollow :exec
+INSERT INTO remote_follows(user_id, actor_id, activity_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    NOW()
+)
+ON CONFLICT (user_id, actor_id) DO UPDATE SET activity_id = EXCLUDED.activity_id;
+
+-- name: DeleteRemoteFollow :exec
+DELETE FROM remote_follows
+WHERE actor_id = sqlc.arg('actor_id')
+    AND (activity_id = sqlc.arg('activity_id') OR user_id = sqlc.narg('user_id'));
+
+-- name: CountFollowers :one
+SELECT (SELECT COUNT(*) FROM follows WHERE followee_id = $1)
+    + (SELECT COUNT(*) FROM remote_follows WHERE user_id = $1);
+
+-- name: GetFollowerInboxes :many
+SELECT DISTINCT remote_actors.shared_inbox FROM remote_follows
+JOIN remote_actors ON remote_actors.id = remote_follows.actor_id
+WHERE remote_follows.user_id = $1;
+
+-- name: InsertRemoteLike :exec
+INSERT INTO remote_likes(chirp_id, actor_id, activity_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    NOW()
+)
+ON CONFLICT (chirp_id, actor_id) DO NOTHING;
+
+-- name: DeleteRemoteLike :exec
+DELETE FROM remote_likes
+WHERE actor_id = sqlc.arg('actor_id')
+    AND (activity_id = sqlc.arg('activity_id') OR chirp_id = sqlc.narg('chirp_id'));
+
+-- name: InsertRemoteNote :exec
+INSERT INTO remote_notes(id, uri, actor_id, in_reply_to, content, published_at, created_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    NOW()
+)
+ON CONFLICT (uri) DO NOTHING;
+
+-- name: CountOutboxChirps :one
+SELECT COUNT(*) FROM chirps
+WHERE user_id = $1
+    AND deleted_at IS NULL
+    AND scheduled_at IS NULL
+    AND visibility = 'public';
+
+-- name: InsertDelivery :exec
+INSERT INTO ap_deliveries(id, created_at, user_id, inbox, activity, next_attempt_at)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    NOW()
+);
+
+-- name: ClaimDueDeliveries :many
+UPDATE ap_deliveries
+SET next_attempt_at = NOW() + INTERVAL '5 minutes'
+WHERE id IN (
+    SELECT id FROM ap_deliveries
+    WHERE next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: DeleteDelivery :exec
+DELETE FROM ap_deliveries
+WHERE id = $1;
+
+-- name: RetryDelivery :exec
+UPDATE ap_deliveries
+SET attempts = attempts + 1,
+    next_attempt_at = NOW() + INTERVAL '1 minute' * power(2, attempts),
+    last_error = $1
+WHERE id = $2;
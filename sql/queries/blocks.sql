@@ -0,0 +1,46 @@
+-- name: BlockUser :exec
+INSERT INTO blocks(blocker_id, blocked_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :exec
+DELETE FROM blocks
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: IsBlockedEitherWay :one
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocker_id = sqlc.arg('user_id') AND blocked_id = sqlc.arg('other_id'))
+        OR (blocker_id = sqlc.arg('other_id') AND blocked_id = sqlc.arg('user_id'))
+) AS blocked;
+
+-- name: ListBlocks :many
+SELECT blocked_id AS user_id, created_at FROM blocks
+WHERE blocker_id = $1
+ORDER BY created_at DESC, blocked_id DESC;
+
+-- name: GetBlockersOf :many
+SELECT blocker_id FROM blocks
+WHERE blocked_id = sqlc.arg('blocked_id') AND blocker_id = ANY(sqlc.arg('user_ids')::uuid[]);
+
+-- name: MuteUser :exec
+INSERT INTO mutes(muter_id, muted_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnmuteUser :exec
+DELETE FROM mutes
+WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: ListMutes :many
+SELECT muted_id AS user_id, created_at FROM mutes
+WHERE muter_id = $1
+ORDER BY created_at DESC, muted_id DESC;
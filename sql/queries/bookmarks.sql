@@ -0,0 +1,33 @@
+-- name: BookmarkChirp :exec
+INSERT INTO bookmarks(id, created_at, user_id, chirp_id, collection_id)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (user_id, chirp_id) DO UPDATE SET collection_id = EXCLUDED.collection_id;
+
+-- name: UnbookmarkChirp :exec
+DELETE FROM bookmarks
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetBookmarkedChirpIDs :many
+SELECT chirp_id FROM bookmarks
+WHERE user_id = sqlc.arg('user_id') AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: ListBookmarks :many
+SELECT sqlc.embed(chirps), bookmarks.created_at AS bookmarked_at FROM bookmarks
+JOIN chirps ON chirps.id = bookmarks.chirp_id
+WHERE bookmarks.user_id = sqlc.arg('user_id')
+    AND (sqlc.narg('collection_id')::uuid IS NULL OR bookmarks.collection_id = sqlc.narg('collection_id'))
+    AND chirps.deleted_at IS NULL
+    AND chirps.scheduled_at IS NULL
+    AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg('user_id'))
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (bookmarks.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY bookmarks.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('max_rows');
@@ -0,0 +1,40 @@
+-- name: InsertChirpHashtag :exec
+INSERT INTO chirp_hashtags(chirp_id, tag, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpHashtags :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = $1;
+
+-- name: ListHashtagChirps :many
+SELECT sqlc.embed(chirps) FROM chirp_hashtags
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirp_hashtags.tag = sqlc.arg('tag')
+    AND chirps.deleted_at IS NULL
+    AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('max_rows');
+
+-- name: GetTrendingHashtags :many
+SELECT chirp_hashtags.tag, COUNT(*) AS chirp_count FROM chirp_hashtags
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirp_hashtags.created_at >= sqlc.arg('since')
+    AND chirps.deleted_at IS NULL
+    AND chirps.visibility = 'public'
+GROUP BY chirp_hashtags.tag
+ORDER BY chirp_count DESC, tag
+LIMIT sqlc.arg('max_rows');
+
+-- name: GetHashtagFeedUpdatedAt :one
+SELECT COALESCE(MAX(chirps.updated_at), 'epoch')::timestamp AS updated_at FROM chirp_hashtags
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirp_hashtags.tag = $1 AND chirps.scheduled_at IS NULL AND chirps.visibility = 'public';
@@ -0,0 +1,13 @@
+-- name: InsertChirpRevision :exec
+INSERT INTO chirp_revisions(id, created_at, chirp_id, body)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3
+);
+
+-- name: GetChirpRevisions :many
+SELECT * FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY created_at DESC;
@@ -1,27 +1,128 @@
 -- name: InsertChirp :one
-INSERT INTO chirps(id, created_at, updated_at, body, user_id)
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, in_reply_to, thread_id, quote_of, scheduled_at, visibility)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
 )
 RETURNING *;
 
--- name: GetAllChirps :many
+-- name: ListChirps :many
 SELECT * FROM chirps
-ORDER BY created_at;
+WHERE deleted_at IS NULL
+    AND scheduled_at IS NULL
+    AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))
+    AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY created_at, id
+LIMIT sqlc.arg('max_rows');
+
+-- name: ListChirpsDesc :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+    AND scheduled_at IS NULL
+    AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))
+    AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('max_rows');
 
 -- name: GetOneChirp :one
 SELECT * FROM chirps
 WHERE id = $1;
 
--- name: DeleteChirp :exec
+-- name: SoftDeleteChirp :exec
+UPDATE chirps
+SET deleted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: PurgeDeletedChirps :exec
 DELETE FROM chirps
-WHERE id = $1;
+WHERE deleted_at < $1;
 
 -- name: GetChirpByUserID :many
 SELECT * FROM chirps
-WHERE user_id = $1
-ORDER BY created_at;
\ No newline at end of file
+WHERE user_id = $1 AND deleted_at IS NULL AND scheduled_at IS NULL
+ORDER BY created_at;
+
+-- name: GetThread :many
+SELECT sqlc.embed(chirps),
+    chirp_visible(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))::boolean AS visible
+FROM chirps
+WHERE (chirps.id = sqlc.arg('thread_id') OR chirps.thread_id = sqlc.arg('thread_id'))
+    AND chirps.scheduled_at IS NULL
+ORDER BY chirps.created_at, chirps.id;
+
+-- name: CountReplies :many
+SELECT in_reply_to, COUNT(*) AS reply_count FROM chirps
+WHERE in_reply_to = ANY(sqlc.arg('chirp_ids')::uuid[]) AND deleted_at IS NULL AND scheduled_at IS NULL
+GROUP BY in_reply_to;
+
+-- name: GetChirpForUpdate :one
+SELECT * FROM chirps
+WHERE id = $1
+FOR UPDATE;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $1,
+    updated_at = NOW(),
+    edited_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg('chirp_ids')::uuid[])
+    AND deleted_at IS NULL
+    AND scheduled_at IS NULL
+    AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'));
+
+-- name: CountQuotes :many
+SELECT quote_of, COUNT(*) AS quote_count FROM chirps
+WHERE quote_of = ANY(sqlc.arg('chirp_ids')::uuid[]) AND deleted_at IS NULL AND scheduled_at IS NULL
+GROUP BY quote_of;
+
+-- name: ListScheduledChirps :many
+SELECT * FROM chirps
+WHERE user_id = $1 AND scheduled_at IS NOT NULL AND deleted_at IS NULL
+ORDER BY scheduled_at, id;
+
+-- name: GetDueChirps :many
+SELECT * FROM chirps
+WHERE scheduled_at <= NOW() AND deleted_at IS NULL
+ORDER BY scheduled_at, id
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: PublishChirp :one
+UPDATE chirps
+SET created_at = NOW(),
+    updated_at = NOW(),
+    edited_at = NULL,
+    scheduled_at = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: IsChirpVisible :one
+SELECT chirp_visible(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))::boolean AS visible FROM chirps
+WHERE chirps.id = sqlc.arg('id');
+
+-- name: GetUserFeedUpdatedAt :one
+SELECT COALESCE(MAX(updated_at), 'epoch')::timestamp AS updated_at FROM chirps
+WHERE user_id = $1 AND scheduled_at IS NULL AND visibility = 'public';
@@ -0,0 +1,30 @@
+-- name: InsertCollection :one
+INSERT INTO collections(id, created_at, updated_at, user_id, name)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2
+)
+RETURNING *;
+
+-- name: ListCollections :many
+SELECT * FROM collections
+WHERE user_id = $1
+ORDER BY lower(name), id;
+
+-- name: GetCollection :one
+SELECT * FROM collections
+WHERE id = $1;
+
+-- name: RenameCollection :one
+UPDATE collections
+SET name = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: DeleteCollection :exec
+DELETE FROM collections
+WHERE id = $1;
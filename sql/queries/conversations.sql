@@ -0,0 +1,98 @@
+-- name: InsertConversation :one
+INSERT INTO conversations(id, created_at, updated_at, created_by, is_direct)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2
+)
+RETURNING *;
+
+-- name: InsertConversationMember :exec
+INSERT INTO conversation_members(conversation_id, user_id, joined_at, last_read_at)
+VALUES (
+    $1,
+    $2,
+    NOW(),
+    NOW()
+);
+
+-- name: InsertDirectConversation :one
+INSERT INTO conversations(id, created_at, updated_at, created_by, is_direct, direct_low, direct_high)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    sqlc.arg('created_by'),
+    TRUE,
+    LEAST(sqlc.arg('created_by')::uuid, sqlc.arg('other_id')::uuid),
+    GREATEST(sqlc.arg('created_by')::uuid, sqlc.arg('other_id')::uuid)
+)
+ON CONFLICT (direct_low, direct_high) DO NOTHING
+RETURNING *;
+
+-- name: FindDirectConversation :one
+SELECT * FROM conversations
+WHERE direct_low = LEAST(sqlc.arg('user_id')::uuid, sqlc.arg('other_id')::uuid)
+    AND direct_high = GREATEST(sqlc.arg('user_id')::uuid, sqlc.arg('other_id')::uuid);
+
+-- name: GetConversationForMember :one
+SELECT conversations.* FROM conversations
+JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversations.id = sqlc.arg('conversation_id') AND conversation_members.user_id = sqlc.arg('user_id');
+
+-- name: GetConversationMembers :many
+SELECT * FROM conversation_members
+WHERE conversation_id = ANY(sqlc.arg('conversation_ids')::uuid[])
+ORDER BY conversation_id, joined_at, user_id;
+
+-- name: ListConversations :many
+SELECT sqlc.embed(conversations),
+    (
+        SELECT COUNT(*) FROM messages
+        WHERE messages.conversation_id = conversations.id
+            AND messages.sender_id <> sqlc.arg('user_id')
+            AND messages.created_at > conversation_members.last_read_at
+    ) AS unread_count
+FROM conversations
+JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversation_members.user_id = sqlc.arg('user_id')
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (conversations.updated_at, conversations.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY conversations.updated_at DESC, conversations.id DESC
+LIMIT sqlc.arg('max_rows');
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_members
+SET last_read_at = NOW()
+WHERE conversation_id = $1 AND user_id = $2;
+
+-- name: CountUnreadMessages :one
+SELECT COUNT(*) FROM messages
+JOIN conversation_members ON conversation_members.conversation_id = messages.conversation_id
+WHERE conversation_members.user_id = sqlc.arg('user_id')
+    AND messages.sender_id <> sqlc.arg('user_id')
+    AND messages.created_at > conversation_members.last_read_at;
+
+-- name: HasBlockWithAny :one
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocker_id = sqlc.arg('user_id') AND blocked_id = ANY(sqlc.arg('other_ids')::uuid[]))
+        OR (blocked_id = sqlc.arg('user_id') AND blocker_id = ANY(sqlc.arg('other_ids')::uuid[]))
+) AS blocked;
+
+-- name: IsBlockedInConversation :one
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    JOIN conversation_members ON conversation_members.user_id = blocks.blocker_id
+    WHERE conversation_members.conversation_id = sqlc.arg('conversation_id')
+        AND blocks.blocked_id = sqlc.arg('user_id')
+) AS blocked;
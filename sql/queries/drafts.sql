@@ -0,0 +1,30 @@
+-- name: InsertDraft :one
+INSERT INTO drafts(id, created_at, updated_at, user_id, body)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2
+)
+RETURNING *;
+
+-- name: ListDrafts :many
+SELECT * FROM drafts
+WHERE user_id = $1
+ORDER BY updated_at DESC, id DESC;
+
+-- name: GetDraft :one
+SELECT * FROM drafts
+WHERE id = $1;
+
+-- name: UpdateDraft :one
+UPDATE drafts
+SET body = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: DeleteDraft :exec
+DELETE FROM drafts
+WHERE id = $1;
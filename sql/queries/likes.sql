@@ -0,0 +1,40 @@
+-- name: LikeChirp :exec
+INSERT INTO likes(id, created_at, user_id, chirp_id)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2
+)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: UnlikeChirp :exec
+DELETE FROM likes
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: CountLikes :many
+SELECT chirp_id, COUNT(*) AS like_count FROM (
+    SELECT chirp_id FROM likes
+    WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+    UNION ALL
+    SELECT chirp_id FROM remote_likes
+    WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+) AS all_likes
+GROUP BY chirp_id;
+
+-- name: GetLikedChirpIDs :many
+SELECT chirp_id FROM likes
+WHERE user_id = sqlc.arg('user_id') AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: ListUserLikes :many
+SELECT sqlc.embed(chirps), likes.created_at AS liked_at FROM likes
+JOIN chirps ON chirps.id = likes.chirp_id
+WHERE likes.user_id = sqlc.arg('user_id')
+    AND chirps.deleted_at IS NULL
+    AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (likes.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY likes.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('max_rows');
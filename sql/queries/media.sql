@@ -0,0 +1,49 @@
+-- name: InsertMedia :one
+INSERT INTO media(id, created_at, user_id, storage_key, content_type, size_bytes, width, height, blurhash)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+RETURNING *;
+
+-- name: InsertMediaRendition :exec
+INSERT INTO media_renditions(media_id, name, storage_key, content_type, width, height, size_bytes)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+);
+
+-- name: GetMediaRenditions :many
+SELECT * FROM media_renditions
+WHERE media_id = ANY(sqlc.arg('media_ids')::uuid[]);
+
+-- name: AttachMedia :execrows
+UPDATE media
+SET chirp_id = sqlc.arg('chirp_id'),
+    position = sqlc.arg('position')
+WHERE id = sqlc.arg('id')
+    AND user_id = sqlc.arg('user_id')
+    AND chirp_id IS NULL;
+
+-- name: GetMediaForChirps :many
+SELECT * FROM media
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: GetMediaOwners :many
+SELECT DISTINCT media.user_id, media.chirp_id FROM media
+LEFT JOIN media_renditions ON media_renditions.media_id = media.id
+WHERE media.storage_key = sqlc.arg('storage_key')
+    OR media_renditions.storage_key = sqlc.arg('storage_key');
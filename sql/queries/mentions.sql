@@ -0,0 +1,36 @@
+-- name: InsertMention :exec
+INSERT INTO mentions(chirp_id, user_id, handle, start_offset, end_offset)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM mentions
+WHERE chirp_id = $1;
+
+-- name: GetMentionsForChirps :many
+SELECT * FROM mentions
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, start_offset;
+
+-- name: ListMentionChirps :many
+SELECT * FROM chirps
+WHERE id IN (SELECT chirp_id FROM mentions WHERE mentions.user_id = sqlc.arg('user_id'))
+    AND deleted_at IS NULL
+    AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg('user_id'))
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.arg('user_id'))
+            OR (blocks.blocker_id = sqlc.arg('user_id') AND blocks.blocked_id = chirps.user_id)
+    )
+    AND user_id NOT IN (SELECT muted_id FROM mutes WHERE mutes.muter_id = sqlc.arg('user_id'))
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('max_rows');
@@ -0,0 +1,107 @@
+-- name: InsertNotification :many
+INSERT INTO notifications(id, created_at, user_id, actor_id, type, chirp_id, group_key)
+SELECT
+    gen_random_uuid(),
+    NOW(),
+    sqlc.arg('user_id')::uuid,
+    sqlc.arg('actor_id')::uuid,
+    sqlc.arg('type')::text,
+    sqlc.narg('chirp_id')::uuid,
+    sqlc.arg('type')::text || ':' || COALESCE(sqlc.narg('chirp_id')::uuid::text, to_char(NOW(), 'YYYY-MM-DD'))
+WHERE sqlc.arg('user_id')::uuid <> sqlc.arg('actor_id')::uuid
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = sqlc.arg('user_id')::uuid AND blocks.blocked_id = sqlc.arg('actor_id')::uuid)
+            OR (blocks.blocker_id = sqlc.arg('actor_id')::uuid AND blocks.blocked_id = sqlc.arg('user_id')::uuid)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.arg('user_id')::uuid AND mutes.muted_id = sqlc.arg('actor_id')::uuid
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM notification_preferences
+        WHERE notification_preferences.user_id = sqlc.arg('user_id')::uuid
+            AND notification_preferences.type = sqlc.arg('type')::text
+            AND NOT notification_preferences.enabled
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM notifications
+        WHERE notifications.user_id = sqlc.arg('user_id')::uuid
+            AND notifications.actor_id = sqlc.arg('actor_id')::uuid
+            AND notifications.type = sqlc.arg('type')::text
+            AND notifications.chirp_id IS NOT DISTINCT FROM sqlc.narg('chirp_id')::uuid
+    )
+    AND (
+        sqlc.narg('chirp_id')::uuid IS NULL
+        OR EXISTS (
+            SELECT 1 FROM chirps
+            WHERE chirps.id = sqlc.narg('chirp_id')::uuid
+                AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg('user_id')::uuid)
+        )
+    )
+RETURNING *;
+
+-- name: ListNotificationGroups :many
+SELECT
+    (array_agg(id ORDER BY created_at DESC, id DESC))[1]::uuid AS id,
+    group_key,
+    type,
+    chirp_id,
+    MAX(created_at)::timestamp AS latest_at,
+    COUNT(*) AS actor_count,
+    bool_and(read_at IS NOT NULL) AS read
+FROM notifications
+WHERE user_id = sqlc.arg('user_id')
+    AND actor_id NOT IN (SELECT muted_id FROM mutes WHERE mutes.muter_id = sqlc.arg('user_id'))
+    AND actor_id NOT IN (SELECT blocked_id FROM blocks WHERE blocks.blocker_id = sqlc.arg('user_id'))
+    AND (chirp_id IS NULL OR chirp_id IN (SELECT chirps.id FROM chirps WHERE chirps.deleted_at IS NULL))
+GROUP BY group_key, type, chirp_id
+HAVING sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (MAX(created_at), (array_agg(id ORDER BY created_at DESC, id DESC))[1]::uuid) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+ORDER BY latest_at DESC, id DESC
+LIMIT sqlc.arg('max_rows');
+
+-- name: GetNotificationActors :many
+SELECT group_key, actor_id FROM (
+    SELECT group_key, actor_id, row_number() OVER (PARTITION BY group_key ORDER BY created_at DESC, id DESC) AS rank
+    FROM notifications
+    WHERE user_id = sqlc.arg('user_id')
+        AND group_key = ANY(sqlc.arg('group_keys')::text[])
+        AND actor_id NOT IN (SELECT muted_id FROM mutes WHERE mutes.muter_id = sqlc.arg('user_id'))
+        AND actor_id NOT IN (SELECT blocked_id FROM blocks WHERE blocks.blocker_id = sqlc.arg('user_id'))
+) ranked
+WHERE rank <= sqlc.arg('max_actors')
+ORDER BY group_key, rank;
+
+-- name: GetNotification :one
+SELECT * FROM notifications
+WHERE id = $1 AND user_id = $2;
+
+-- name: MarkNotificationGroupRead :exec
+UPDATE notifications SET read_at = NOW()
+WHERE user_id = $1 AND group_key = $2 AND read_at IS NULL;
+
+-- name: MarkAllNotificationsRead :exec
+UPDATE notifications SET read_at = NOW()
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(DISTINCT group_key) FROM notifications
+WHERE user_id = sqlc.arg('user_id')
+    AND read_at IS NULL
+    AND actor_id NOT IN (SELECT muted_id FROM mutes WHERE mutes.muter_id = sqlc.arg('user_id'))
+    AND actor_id NOT IN (SELECT blocked_id FROM blocks WHERE blocks.blocker_id = sqlc.arg('user_id'))
+    AND (chirp_id IS NULL OR chirp_id IN (SELECT chirps.id FROM chirps WHERE chirps.deleted_at IS NULL));
+
+-- name: GetNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE user_id = $1;
+
+-- name: SetNotificationPreference :exec
+INSERT INTO notification_preferences(user_id, type, enabled)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (user_id, type) DO UPDATE SET enabled = EXCLUDED.enabled;
@@ -0,0 +1,36 @@
+-- name: PinChirp :exec
+INSERT INTO pins(user_id, chirp_id, position, created_at)
+VALUES (
+    sqlc.arg('user_id'),
+    sqlc.arg('chirp_id'),
+    (SELECT COALESCE(MAX(position) + 1, 0) FROM pins WHERE user_id = sqlc.arg('user_id')),
+    NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: UnpinChirp :exec
+DELETE FROM pins
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: DeleteChirpPins :exec
+DELETE FROM pins
+WHERE chirp_id = $1;
+
+-- name: GetPinnedChirpIDs :many
+SELECT chirp_id FROM pins
+WHERE user_id = $1
+ORDER BY position, created_at;
+
+-- name: SetPinPosition :exec
+UPDATE pins
+SET position = $1
+WHERE user_id = $2 AND chirp_id = $3;
+
+-- name: ListPinnedChirps :many
+SELECT sqlc.embed(chirps) FROM pins
+JOIN chirps ON chirps.id = pins.chirp_id
+WHERE pins.user_id = sqlc.arg('user_id')
+    AND chirps.deleted_at IS NULL
+    AND chirps.scheduled_at IS NULL
+    AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))
+ORDER BY pins.position, pins.created_at;
@@ -0,0 +1,38 @@
+-- name: SearchChirpsByRelevance :many
+SELECT sqlc.embed(chirps),
+    ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', sqlc.arg('query'))) AS rank,
+    ts_headline('english', html_escape(chirps.body), websearch_to_tsquery('english', sqlc.arg('query')), 'StartSel=<mark>, StopSel=</mark>') AS snippet
+FROM chirps
+WHERE chirps.deleted_at IS NULL
+    AND chirps.scheduled_at IS NULL
+    AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))
+    AND (sqlc.arg('query')::text = '' OR to_tsvector('english', chirps.body) @@ websearch_to_tsquery('english', sqlc.arg('query')))
+    AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id'))
+    AND (sqlc.narg('since')::timestamp IS NULL OR chirps.created_at >= sqlc.narg('since'))
+    AND (sqlc.narg('until')::timestamp IS NULL OR chirps.created_at < sqlc.narg('until'))
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', sqlc.arg('query'))), chirps.created_at, chirps.id)
+            < (sqlc.narg('cursor_rank')::real, sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('max_rows');
+
+-- name: SearchChirpsByRecency :many
+SELECT sqlc.embed(chirps),
+    ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', sqlc.arg('query'))) AS rank,
+    ts_headline('english', html_escape(chirps.body), websearch_to_tsquery('english', sqlc.arg('query')), 'StartSel=<mark>, StopSel=</mark>') AS snippet
+FROM chirps
+WHERE chirps.deleted_at IS NULL
+    AND chirps.scheduled_at IS NULL
+    AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))
+    AND (sqlc.arg('query')::text = '' OR to_tsvector('english', chirps.body) @@ websearch_to_tsquery('english', sqlc.arg('query')))
+    AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id'))
+    AND (sqlc.narg('since')::timestamp IS NULL OR chirps.created_at >= sqlc.narg('since'))
+    AND (sqlc.narg('until')::timestamp IS NULL OR chirps.created_at < sqlc.narg('until'))
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('max_rows');
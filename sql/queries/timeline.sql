@@ -0,0 +1,39 @@
+-- name: ListTimeline :many
+SELECT sqlc.embed(chirps), timeline.entry_id, timeline.entry_at, timeline.rechirped_by FROM (
+    SELECT id AS chirp_id, id AS entry_id, created_at AS entry_at, NULL::uuid AS rechirped_by
+    FROM chirps
+    WHERE chirps.user_id = sqlc.arg('user_id')
+        OR chirps.user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.arg('user_id'))
+    UNION ALL
+    SELECT chirp_id, id, created_at, user_id
+    FROM rechirps
+    WHERE rechirps.user_id = sqlc.arg('user_id')
+        OR rechirps.user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.arg('user_id'))
+) AS timeline
+JOIN chirps ON chirps.id = timeline.chirp_id
+WHERE chirps.deleted_at IS NULL
+    AND chirps.scheduled_at IS NULL
+    AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg('user_id'))
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.arg('user_id')
+            AND (mutes.muted_id = chirps.user_id OR mutes.muted_id = timeline.rechirped_by)
+    )
+    AND (
+        sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (timeline.entry_at, timeline.entry_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+    )
+ORDER BY timeline.entry_at DESC, timeline.entry_id DESC
+LIMIT sqlc.arg('max_rows');
+
+-- name: IsInTimeline :one
+SELECT (
+    sqlc.arg('author_id')::uuid = sqlc.arg('user_id')::uuid
+    OR EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = sqlc.arg('user_id')::uuid AND follows.followee_id = sqlc.arg('author_id')::uuid
+    )
+) AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.arg('user_id')::uuid AND mutes.muted_id = sqlc.arg('author_id')::uuid
+) AS in_timeline;
@@ -14,8 +14,47 @@ TRUNCATE TABLE users;
 
 -- name: UpdateEmailPassword :one
 UPDATE users
-SET email = $1,
-    hashed_password = $2,
+SET email = COALESCE(sqlc.narg('email'), email),
+    hashed_password = COALESCE(sqlc.narg('hashed_password'), hashed_password),
     updated_at = NOW()
-WHERE id = $3
-RETURNING *;
\ No newline at end of file
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: GetUserForUpdate :one
+SELECT * FROM users
+WHERE id = $1
+FOR UPDATE;
+
+
+-- name: GetUsersByHandles :many
+SELECT id, handle FROM users
+WHERE lower(handle) = ANY(sqlc.arg('handles')::text[]);
+
+-- name: GetUsersByIDs :many
+SELECT id, handle, display_name FROM users
+WHERE id = ANY(sqlc.arg('user_ids')::uuid[]);
+
+
+-- name: GetUserByHandle :one
+SELECT * FROM users
+WHERE lower(handle) = lower(sqlc.arg('handle'));
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET handle = COALESCE(sqlc.narg('handle'), handle),
+    display_name = COALESCE(sqlc.narg('display_name'), display_name),
+    bio = COALESCE(sqlc.narg('bio'), bio),
+    avatar_url = COALESCE(sqlc.narg('avatar_url'), avatar_url),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: GetProfileCounts :one
+SELECT
+    (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = sqlc.arg('user_id') AND chirps.deleted_at IS NULL AND chirps.scheduled_at IS NULL) AS chirp_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.followee_id = sqlc.arg('user_id')) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.follower_id = sqlc.arg('user_id')) AS following_count;
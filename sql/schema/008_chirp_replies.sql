@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN in_reply_to UUID DEFAULT NULL,
+ADD COLUMN thread_id UUID DEFAULT NULL;
+
+CREATE INDEX chirps_in_reply_to_idx ON chirps (in_reply_to);
+CREATE INDEX chirps_thread_id_idx ON chirps (thread_id);
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN thread_id,
+DROP COLUMN in_reply_to;
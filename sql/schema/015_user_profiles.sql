@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+ADD COLUMN bio TEXT NOT NULL DEFAULT '',
+ADD COLUMN avatar_url TEXT NOT NULL DEFAULT '',
+ADD CONSTRAINT users_handle_format CHECK (handle ~ '^[A-Za-z0-9_]{3,30}$');
+
+-- +goose Down
+ALTER TABLE users
+DROP CONSTRAINT users_handle_format,
+DROP COLUMN avatar_url,
+DROP COLUMN bio,
+DROP COLUMN display_name;
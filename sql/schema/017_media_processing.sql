@@ -0,0 +1,24 @@
+-- +goose Up
+ALTER TABLE media
+ADD COLUMN width INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN height INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN blurhash TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE media_renditions(
+    media_id UUID NOT NULL REFERENCES media (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    storage_key TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    PRIMARY KEY (media_id, name)
+);
+
+-- +goose Down
+DROP TABLE media_renditions;
+
+ALTER TABLE media
+DROP COLUMN blurhash,
+DROP COLUMN height,
+DROP COLUMN width;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN scheduled_at TIMESTAMP DEFAULT NULL;
+
+CREATE INDEX chirps_scheduled_at_idx ON chirps (scheduled_at) WHERE scheduled_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_scheduled_at_idx;
+
+ALTER TABLE chirps
+DROP COLUMN scheduled_at;
@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+    CHECK (visibility IN ('public', 'followers', 'mentioned'));
+
+-- chirp_visible decides whether viewer_id may read a chirp. viewer_id is NULL for anonymous requests.
+-- Mentioned users can always read the chirp, whatever its visibility.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_visible(chirp_id UUID, author_id UUID, visibility TEXT, viewer_id UUID)
+RETURNS BOOLEAN AS $$
+    SELECT $3 = 'public'
+        OR COALESCE($2 = $4, FALSE)
+        OR ($3 = 'followers' AND EXISTS (
+            SELECT 1 FROM follows WHERE follows.follower_id = $4 AND follows.followee_id = $2
+        ))
+        OR EXISTS (
+            SELECT 1 FROM mentions WHERE mentions.chirp_id = $1 AND mentions.user_id = $4
+        )
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_visible;
+
+ALTER TABLE chirps
+DROP COLUMN visibility;
@@ -0,0 +1,54 @@
+-- +goose Up
+CREATE TABLE blocks(
+    blocker_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (blocker_id, blocked_id)
+);
+
+CREATE INDEX blocks_blocked_id_idx ON blocks (blocked_id);
+
+CREATE TABLE mutes(
+    muter_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    muted_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (muter_id, muted_id)
+);
+
+-- a blocked user can't see any chirp of the user who blocked them
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible(chirp_id UUID, author_id UUID, visibility TEXT, viewer_id UUID)
+RETURNS BOOLEAN AS $$
+    SELECT NOT EXISTS (
+            SELECT 1 FROM blocks WHERE blocks.blocker_id = $2 AND blocks.blocked_id = $4
+        )
+        AND (
+            $3 = 'public'
+            OR COALESCE($2 = $4, FALSE)
+            OR ($3 = 'followers' AND EXISTS (
+                SELECT 1 FROM follows WHERE follows.follower_id = $4 AND follows.followee_id = $2
+            ))
+            OR EXISTS (
+                SELECT 1 FROM mentions WHERE mentions.chirp_id = $1 AND mentions.user_id = $4
+            )
+        )
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible(chirp_id UUID, author_id UUID, visibility TEXT, viewer_id UUID)
+RETURNS BOOLEAN AS $$
+    SELECT $3 = 'public'
+        OR COALESCE($2 = $4, FALSE)
+        OR ($3 = 'followers' AND EXISTS (
+            SELECT 1 FROM follows WHERE follows.follower_id = $4 AND follows.followee_id = $2
+        ))
+        OR EXISTS (
+            SELECT 1 FROM mentions WHERE mentions.chirp_id = $1 AND mentions.user_id = $4
+        )
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+DROP TABLE mutes;
+DROP TABLE blocks;
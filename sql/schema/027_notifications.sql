@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE notifications(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type TEXT NOT NULL CHECK (type IN ('reply', 'like', 'follow', 'mention')),
+    chirp_id UUID REFERENCES chirps (id) ON DELETE CASCADE,
+    -- notifications sharing a group_key are listed as one entry,
+    -- e.g. all likes of a chirp or all follows of a day
+    group_key TEXT NOT NULL,
+    read_at TIMESTAMP
+);
+
+CREATE INDEX notifications_user_id_created_at_idx ON notifications (user_id, created_at DESC);
+CREATE INDEX notifications_user_id_group_key_idx ON notifications (user_id, group_key);
+
+CREATE TABLE notification_preferences(
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type TEXT NOT NULL CHECK (type IN ('reply', 'like', 'follow', 'mention')),
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, type)
+);
+
+-- +goose Down
+DROP TABLE notification_preferences;
+DROP TABLE notifications;
@@ -0,0 +1,68 @@
+-- +goose Up
+-- signing keys of local actors, created the first time a user federates
+CREATE TABLE actor_keys(
+    user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    public_key_pem TEXT NOT NULL,
+    private_key_pem TEXT NOT NULL
+);
+
+CREATE TABLE remote_actors(
+    id UUID PRIMARY KEY,
+    uri TEXT NOT NULL UNIQUE,
+    username TEXT NOT NULL,
+    inbox TEXT NOT NULL,
+    shared_inbox TEXT NOT NULL,
+    key_id TEXT NOT NULL UNIQUE,
+    public_key_pem TEXT NOT NULL,
+    fetched_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE remote_follows(
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL REFERENCES remote_actors (id) ON DELETE CASCADE,
+    activity_id TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, actor_id)
+);
+
+CREATE TABLE remote_likes(
+    chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL REFERENCES remote_actors (id) ON DELETE CASCADE,
+    activity_id TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, actor_id)
+);
+
+-- notes other servers sent us, replies to and mentions of local users
+CREATE TABLE remote_notes(
+    id UUID PRIMARY KEY,
+    uri TEXT NOT NULL UNIQUE,
+    actor_id UUID NOT NULL REFERENCES remote_actors (id) ON DELETE CASCADE,
+    in_reply_to UUID REFERENCES chirps (id) ON DELETE SET NULL,
+    content TEXT NOT NULL,
+    published_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- outgoing activities, retried with backoff until the remote inbox takes them
+CREATE TABLE ap_deliveries(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    inbox TEXT NOT NULL,
+    activity JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX ap_deliveries_next_attempt_at_idx ON ap_deliveries (next_attempt_at);
+
+-- +goose Down
+DROP TABLE ap_deliveries;
+DROP TABLE remote_notes;
+DROP TABLE remote_likes;
+DROP TABLE remote_follows;
+DROP TABLE remote_actors;
+DROP TABLE actor_keys;
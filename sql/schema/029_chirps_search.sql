@@ -0,0 +1,27 @@
+-- +goose Up
+-- index the expression instead of storing the vector, so SELECT * on chirps doesn't carry it around
+DROP INDEX chirps_search_vector_idx;
+
+ALTER TABLE chirps
+DROP COLUMN search_vector;
+
+CREATE INDEX chirps_search_idx ON chirps USING GIN (to_tsvector('english', body));
+
+-- search snippets are HTML, the chirp body has to be escaped before ts_headline adds its tags
+-- +goose StatementBegin
+CREATE FUNCTION html_escape(input TEXT)
+RETURNS TEXT AS $$
+    SELECT replace(replace(replace(replace(replace($1, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')
+$$ LANGUAGE SQL IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION html_escape;
+
+DROP INDEX chirps_search_idx;
+
+ALTER TABLE chirps
+ADD COLUMN search_vector TSVECTOR
+GENERATED ALWAYS AS (to_tsvector('english', body)) STORED;
+
+CREATE INDEX chirps_search_vector_idx ON chirps USING GIN (search_vector);
@@ -0,0 +1,33 @@
+-- +goose Up
+-- a direct conversation carries its member pair in order, so each pair can have only one
+ALTER TABLE conversations
+ADD COLUMN direct_low UUID DEFAULT NULL,
+ADD COLUMN direct_high UUID DEFAULT NULL;
+
+-- duplicates that already exist keep working, only the oldest one of a pair is found from now on
+UPDATE conversations
+SET direct_low = pairs.members[1],
+    direct_high = pairs.members[2]
+FROM (
+    SELECT DISTINCT ON (members) conversation_id, members
+    FROM (
+        SELECT conversations.id AS conversation_id, conversations.created_at,
+            array_agg(conversation_members.user_id ORDER BY conversation_members.user_id) AS members
+        FROM conversations
+        JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+        WHERE conversations.is_direct
+        GROUP BY conversations.id
+    ) AS direct
+    WHERE array_length(members, 1) = 2
+    ORDER BY members, created_at, conversation_id
+) AS pairs
+WHERE conversations.id = pairs.conversation_id;
+
+CREATE UNIQUE INDEX conversations_direct_pair_idx ON conversations (direct_low, direct_high);
+
+-- +goose Down
+DROP INDEX conversations_direct_pair_idx;
+
+ALTER TABLE conversations
+DROP COLUMN direct_high,
+DROP COLUMN direct_low;
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/entities"
+	"github.com/google/uuid"
+)
+
+const (
+	streamCreated = "created"
+	streamEdited  = "edited"
+	streamDeleted = "deleted"
+)
+
+const (
+	// streamBacklog is how many events a reconnecting client can catch up on.
+	streamBacklog   = 1000
+	streamHeartbeat = 30 * time.Second
+)
+
+// chirpEvent is what the stream hub carries. Data is rendered once, for an anonymous viewer,
+// and every subscriber decides from Chirp and Hashtags whether to receive it.
+type chirpEvent struct {
+	Type     string
+	Chirp    database.Chirp
+	Hashtags []string
+	Data     []byte
+}
+
+type streamFilter struct {
+	authorID uuid.NullUUID
+	hashtag  string
+	timeline bool
+}
+
+// publishChirpEvent pushes a chirp change to the stream. The change is already committed,
+// so a failure is only logged.
+func (cfg *apiConfig) publishChirpEvent(ctx context.Context, kind string, chirp database.Chirp) {
+	var data []byte
+	var err error
+	if kind == streamDeleted {
+		data, err = json.Marshal(chirpRef{ID: chirp.ID, Deleted: true})
+	} else {
+		var respChirps []returnJson
+		respChirps, err = cfg.chirpsToJson(ctx, uuid.Nil, []database.Chirp{chirp})
+		if err == nil {
+			data, err = json.Marshal(respChirps[0])
+		}
+	}
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	cfg.stream.Publish(chirpEvent{
+		Type:     kind,
+		Chirp:    chirp,
+		Hashtags: entities.Hashtags(chirp.Body),
+		Data:     data,
+	})
+}
+
+// streamMatches reports whether viewerID, subscribed with filter, gets the event.
+func (cfg *apiConfig) streamMatches(ctx context.Context, viewerID uuid.UUID, filter streamFilter, event chirpEvent) (bool, error) {
+	if filter.authorID.Valid && event.Chirp.UserID != filter.authorID.UUID {
+		return false, nil
+	}
+
+	if filter.hashtag != "" && !slices.Contains(event.Hashtags, filter.hashtag) {
+		return false, nil
+	}
+
+	if filter.timeline {
+		inTimeline, err := cfg.queries.IsInTimeline(ctx, database.IsInTimelineParams{
+			AuthorID: event.Chirp.UserID,
+			UserID:   viewerID,
+		})
+		if err != nil || !inTimeline {
+			return false, err
+		}
+	}
+
+	return cfg.canSeeChirp(ctx, viewerID, event.Chirp)
+}
+
+// HandlerStream serves chirp changes as Server-Sent Events. ?author_id and ?hashtag narrow the stream,
+// ?scope=timeline limits it to the caller's timeline. A Last-Event-ID replays what the client missed.
+func (cfg *apiConfig) HandlerStream(w http.ResponseWriter, r *http.Request) {
+	viewerID := cfg.viewerID(r)
+	query := r.URL.Query()
+
+	filter := streamFilter{
+		hashtag: strings.ToLower(strings.TrimPrefix(query.Get("hashtag"), "#")),
+	}
+
+	if authorIDstring := query.Get("author_id"); authorIDstring != "" {
+		parsedID, err := uuid.Parse(authorIDstring)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		filter.authorID = uuid.NullUUID{UUID: parsedID, Valid: true}
+	}
+
+	switch query.Get("scope") {
+	case "":
+	case "timeline":
+		if viewerID == uuid.Nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		filter.timeline = true
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// browsers send the header on reconnect, the query parameter covers the first connection.
+	// IDs are "epoch-id", an ID from before a restart replays nothing.
+	var lastEventID uint64
+	rawLastID := r.Header.Get("Last-Event-ID")
+	if rawLastID == "" {
+		rawLastID = query.Get("last_event_id")
+	}
+	if epoch, rawID, ok := strings.Cut(rawLastID, "-"); ok && epoch == cfg.stream.Epoch() {
+		parsedID, err := strconv.ParseUint(rawID, 10, 64)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		lastEventID = parsedID
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// the stream outlives any write timeout the server might have
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	sub, missed := cfg.stream.Subscribe(lastEventID)
+	defer cfg.stream.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	send := func(id uint64, event chirpEvent) bool {
+		matches, err := cfg.streamMatches(r.Context(), viewerID, filter, event)
+		if err != nil {
+			fmt.Println(err)
+			return false
+		}
+		if !matches {
+			return true
+		}
+
+		_, err = fmt.Fprintf(w, "id: %s-%d\nevent: %s\ndata: %s\n\n", cfg.stream.Epoch(), id, event.Type, event.Data)
+		if err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	for _, el := range missed {
+		if !send(el.ID, el.Value) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, err := fmt.Fprint(w, ": ping\n\n")
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		case el, ok := <-sub.Events():
+			// a closed channel means we fell behind or the server is shutting down,
+			// the client reconnects with its Last-Event-ID
+			if !ok {
+				return
+			}
+			if !send(el.ID, el.Value) {
+				return
+			}
+		}
+	}
+}
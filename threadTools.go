@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// threadEntry is a chirp of the thread, or a tombstone: Deleted for a chirp the viewer could read
+// before it was deleted, Unavailable without an id for one they may not know about.
+type threadEntry struct {
+	ID          *uuid.UUID  `json:"id,omitempty"`
+	Depth       int         `json:"depth"`
+	Deleted     bool        `json:"deleted"`
+	Unavailable bool        `json:"unavailable"`
+	Chirp       *returnJson `json:"chirp,omitempty"`
+}
+
+func (cfg *apiConfig) HandlerGetThread(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+
+	chirp, err := cfg.queries.GetOneChirp(r.Context(), chirpID)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if chirp.ScheduledAt.Valid {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	visible, err := cfg.canSeeChirp(r.Context(), viewerID, chirp)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// hidden chirps answer like missing ones so their existence doesn't leak
+	if !visible {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	rootID := chirp.ID
+	if chirp.ThreadID.Valid {
+		rootID = chirp.ThreadID.UUID
+	}
+
+	rows, err := cfg.queries.GetThread(r.Context(), database.GetThreadParams{
+		ViewerID: nullUUID(viewerID),
+		ThreadID: rootID,
+	})
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// the deleted and hidden chirps only give the tree its shape, their content never leaves
+	chirps := []database.Chirp{}
+	for _, el := range rows {
+		if el.Visible && !el.Chirp.DeletedAt.Valid {
+			chirps = append(chirps, el.Chirp)
+		}
+	}
+
+	returnChirps, err := cfg.chirpsToJson(r.Context(), viewerID, chirps)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respData, err := json.Marshal(buildThread(rootID, rows, returnChirps))
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(respData)
+}
+
+// buildThread flattens the reply tree depth-first, so clients can render it top to bottom.
+// A deleted or hidden chirp only shows up as a tombstone when it holds visible replies, at the
+// depth it had. Deleted chirps the viewer could read keep their id, hidden ones are just
+// unavailable, as are parents that were purged and whose place in the tree is gone with them.
+func buildThread(rootID uuid.UUID, rows []database.GetThreadRow, chirps []returnJson) []threadEntry {
+	byID := map[uuid.UUID]*returnJson{}
+	for i := range chirps {
+		byID[chirps[i].ID] = &chirps[i]
+	}
+
+	nodes := map[uuid.UUID]database.GetThreadRow{}
+	for _, el := range rows {
+		nodes[el.Chirp.ID] = el
+	}
+
+	children := map[uuid.UUID][]uuid.UUID{}
+	purged := map[uuid.UUID]bool{}
+	for _, el := range rows {
+		if el.Chirp.ID == rootID {
+			continue
+		}
+
+		parentID := rootID
+		if el.Chirp.InReplyTo.Valid {
+			parentID = el.Chirp.InReplyTo.UUID
+		}
+
+		if _, ok := nodes[parentID]; !ok && parentID != rootID && !purged[parentID] {
+			purged[parentID] = true
+			children[rootID] = append(children[rootID], parentID)
+		}
+
+		children[parentID] = append(children[parentID], el.Chirp.ID)
+	}
+
+	shown := map[uuid.UUID]bool{}
+	var hasVisible func(id uuid.UUID) bool
+	hasVisible = func(id uuid.UUID) bool {
+		visible := byID[id] != nil
+		for _, child := range children[id] {
+			if hasVisible(child) {
+				visible = true
+			}
+		}
+		shown[id] = visible
+		return visible
+	}
+	hasVisible(rootID)
+
+	entries := []threadEntry{}
+	var walk func(id uuid.UUID, depth int)
+	walk = func(id uuid.UUID, depth int) {
+		if !shown[id] && id != rootID {
+			return
+		}
+
+		entry := threadEntry{Depth: depth}
+		node, known := nodes[id]
+		switch {
+		case byID[id] != nil:
+			entry.ID = &id
+			entry.Chirp = byID[id]
+		case known && node.Visible && node.Chirp.DeletedAt.Valid:
+			entry.ID = &id
+			entry.Deleted = true
+		default:
+			entry.Unavailable = true
+		}
+		entries = append(entries, entry)
+
+		for _, child := range children[id] {
+			walk(child, depth+1)
+		}
+	}
+	walk(rootID, 0)
+
+	return entries
+}
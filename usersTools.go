@@ -1,20 +1,35 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
+	"unicode/utf8"
 
 	"github.com/YaroslavalsoraY/Chirpy/internal/auth"
 	"github.com/YaroslavalsoraY/Chirpy/internal/database"
+	"github.com/YaroslavalsoraY/Chirpy/internal/entities"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
 )
 
 type info struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	expires  int
+	Email       string  `json:"email"`
+	Password    string  `json:"password"`
+	Handle      *string `json:"handle"`
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
+	AvatarURL   *string `json:"avatar_url"`
+	expires     int
 }
 
 type User struct {
@@ -25,6 +40,10 @@ type User struct {
 	Token        string    `json:"token"`
 	RefreshToken string    `json:"refresh_token"`
 	IsChirpyRed  bool      `json:"is_chirpy_red"`
+	Handle       string    `json:"handle,omitempty"`
+	DisplayName  string    `json:"display_name,omitempty"`
+	Bio          string    `json:"bio,omitempty"`
+	AvatarURL    string    `json:"avatar_url,omitempty"`
 }
 
 func (cfg *apiConfig) HandlerAddUser(w http.ResponseWriter, r *http.Request) {
@@ -138,6 +157,10 @@ func (cfg *apiConfig) HandlerLogin(w http.ResponseWriter, r *http.Request) {
 		Token:        token,
 		RefreshToken: refreshToken,
 		IsChirpyRed:  userInfo.IsChirpyRed.Bool,
+		Handle:       userInfo.Handle.String,
+		DisplayName:  userInfo.DisplayName,
+		Bio:          userInfo.Bio,
+		AvatarURL:    userInfo.AvatarUrl,
 	}
 	respJson, err := json.Marshal(respData)
 	if err != nil {
@@ -174,19 +197,65 @@ func (cfg *apiConfig) HandlerUpdateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(insertData.Password)
+	profileArgs, err := profileUpdate(insertData)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// only the credentials that were sent change, an empty password is never hashed and stored
+	credentialArgs := database.UpdateEmailPasswordParams{
+		ID: realUserID,
+	}
+	if insertData.Email != "" {
+		credentialArgs.Email = sql.NullString{String: insertData.Email, Valid: true}
+	}
+	if insertData.Password != "" {
+		hashedPassword, err := auth.HashPassword(insertData.Password)
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		credentialArgs.HashedPassword = sql.NullString{String: hashedPassword, Valid: true}
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
 	if err != nil {
 		fmt.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	if credentialArgs.Email.Valid || credentialArgs.HashedPassword.Valid {
+		_, err = qtx.UpdateEmailPassword(r.Context(), credentialArgs)
+		if isUniqueViolation(err) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		if err != nil {
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
 
-	args := database.UpdateEmailPasswordParams{
-		Email:          insertData.Email,
-		HashedPassword: hashedPassword,
-		ID:             realUserID,
+	profileArgs.ID = realUserID
+	user, err := qtx.UpdateUserProfile(r.Context(), profileArgs)
+	if isUniqueViolation(err) {
+		w.WriteHeader(http.StatusConflict)
+		return
 	}
-	user, err := cfg.queries.UpdateEmailPassword(r.Context(), args)
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = tx.Commit()
 	if err != nil {
 		fmt.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -194,10 +263,15 @@ func (cfg *apiConfig) HandlerUpdateUser(w http.ResponseWriter, r *http.Request)
 	}
 
 	returnUser := User{
-		ID:        args.ID,
-		CreatedAt: user.CreatedAt.Time,
-		UpdatedAt: user.UpdatedAt.Time,
-		Email:     args.Email,
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt.Time,
+		UpdatedAt:   user.UpdatedAt.Time,
+		Email:       user.Email,
+		IsChirpyRed: user.IsChirpyRed.Bool,
+		Handle:      user.Handle.String,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		AvatarURL:   user.AvatarUrl,
 	}
 	respData, err := json.Marshal(returnUser)
 	if err != nil {
@@ -208,3 +282,47 @@ func (cfg *apiConfig) HandlerUpdateUser(w http.ResponseWriter, r *http.Request)
 
 	w.Write(respData)
 }
+
+// profileUpdate validates the profile fields that were sent. Fields left out keep their value.
+func profileUpdate(insertData info) (database.UpdateUserProfileParams, error) {
+	args := database.UpdateUserProfileParams{}
+
+	if insertData.Handle != nil {
+		err := entities.ValidateHandle(*insertData.Handle)
+		if err != nil {
+			return args, err
+		}
+		args.Handle = sql.NullString{String: *insertData.Handle, Valid: true}
+	}
+
+	if insertData.DisplayName != nil {
+		if utf8.RuneCountInString(*insertData.DisplayName) > maxDisplayNameLength {
+			return args, errors.New("Display name is too long")
+		}
+		args.DisplayName = sql.NullString{String: *insertData.DisplayName, Valid: true}
+	}
+
+	if insertData.Bio != nil {
+		if utf8.RuneCountInString(*insertData.Bio) > maxBioLength {
+			return args, errors.New("Bio is too long")
+		}
+		args.Bio = sql.NullString{String: *insertData.Bio, Valid: true}
+	}
+
+	if insertData.AvatarURL != nil {
+		if *insertData.AvatarURL != "" {
+			avatarURL, err := url.Parse(*insertData.AvatarURL)
+			if err != nil || (avatarURL.Scheme != "http" && avatarURL.Scheme != "https") || avatarURL.Host == "" {
+				return args, errors.New("Invalid avatar URL")
+			}
+		}
+		args.AvatarUrl = sql.NullString{String: *insertData.AvatarURL, Valid: true}
+	}
+
+	return args, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}